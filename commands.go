@@ -0,0 +1,215 @@
+package main
+
+import (
+	"gator/internal/handlers"
+	"gator/internal/middlewares"
+)
+
+func registerCommands(cmds *handlers.Commands) {
+	cmds.Register(handlers.Spec{
+		Name:            "help",
+		Description:     "List the commands, or show the help of one of them",
+		Args:            []handlers.Arg{{Name: "command", Optional: true}},
+		SkipSchemaCheck: true,
+		Handler:         cmds.Help,
+	})
+	cmds.Register(handlers.Spec{
+		Name:            "completion",
+		Description:     "Print the completion script for a shell",
+		Args:            []handlers.Arg{{Name: "shell", Complete: handlers.Values("bash", "zsh", "fish")}},
+		SkipSchemaCheck: true,
+		Handler:         cmds.Completion,
+	})
+	cmds.Register(handlers.Spec{
+		Name:            "__complete",
+		Description:     "Print the completion candidates for a command line",
+		Args:            []handlers.Arg{{Name: "words", Optional: true, Variadic: true}},
+		Hidden:          true,
+		SkipSchemaCheck: true,
+		Handler:         cmds.Complete,
+	})
+	cmds.Register(handlers.Spec{
+		Name:            "migrate",
+		Description:     "Apply the pending migrations, roll back the last one, or list them",
+		Args:            []handlers.Arg{{Name: "up|down|status", Complete: handlers.Values("up", "down", "status")}},
+		SkipSchemaCheck: true,
+		Handler:         handlers.Migrate,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "register",
+		Description: "Register a new user and login as them",
+		Args:        []handlers.Arg{{Name: "username"}},
+		Handler:     handlers.Register,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "login",
+		Description: "Login as an existing user",
+		Args:        []handlers.Arg{{Name: "username", Complete: handlers.CompleteUsernames}},
+		Handler:     handlers.Login,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "reset",
+		Description: "Delete all users",
+		Handler:     handlers.Reset,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "users",
+		Description: "List all users",
+		Handler:     handlers.Users,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "agg",
+		Description: "Aggregate the posts of the due feeds periodically until interrupted",
+		Args: []handlers.Arg{
+			{Name: "time between reqs"},
+			{Name: "concurrency", Optional: true},
+		},
+		Handler: handlers.Agg,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "addfeed",
+		Aliases:     []string{"add-feed"},
+		Description: "Add a new feed and follow it",
+		Args:        []handlers.Arg{{Name: "feed name"}, {Name: "feed url"}},
+		Handler:     middlewares.LoggedIn(handlers.AddFeed),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "feeds",
+		Description: "List all feeds",
+		Handler:     handlers.Feeds,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "feed",
+		Description: "Inspect the feeds, 'errors' lists the feeds failing to be fetched",
+		Args:        []handlers.Arg{{Name: "errors", Complete: handlers.Values("errors")}},
+		Handler:     handlers.Feed,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "follow",
+		Description: "Follow an existing feed",
+		Args:        []handlers.Arg{{Name: "feed url", Complete: handlers.CompleteFeedURLs}},
+		Handler:     handlers.Follow,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "following",
+		Description: "List the feeds followed by the user",
+		Handler:     handlers.Following,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "unfollow",
+		Description: "Unfollow a feed",
+		Args:        []handlers.Arg{{Name: "feed url", Complete: handlers.CompleteFollowedFeedURLs}},
+		Handler:     middlewares.LoggedIn(handlers.Unfollow),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "import",
+		Description: "Follow all the feeds of an OPML file, creating the missing ones",
+		Args:        []handlers.Arg{{Name: "opml", Complete: handlers.Values("opml")}, {Name: "file"}},
+		Handler:     middlewares.LoggedIn(handlers.Import),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "export",
+		Description: "Export the feeds followed by the user as OPML, to the standard output by default",
+		Args:        []handlers.Arg{{Name: "opml", Complete: handlers.Values("opml")}, {Name: "file", Optional: true}},
+		Handler:     middlewares.LoggedIn(handlers.Export),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "browse",
+		Description: "List the posts of the feeds followed by the user, only the unread ones by default",
+		Args:        []handlers.Arg{{Name: "limit", Optional: true}},
+		Flags: []handlers.Flag{
+			{Name: "all", Type: handlers.BoolFlag, Description: "include the read posts"},
+			{Name: "unread", Type: handlers.BoolFlag, Description: "only list the unread posts, the default"},
+			{Name: "limit", Type: handlers.IntFlag, Value: "n", Description: "number of posts to list, 2 by default"},
+			{Name: "feed", Type: handlers.StringFlag, Value: "url", Description: "only list the posts of a feed",
+				Complete: handlers.CompleteFollowedFeedURLs},
+			{Name: "since", Type: handlers.StringFlag, Value: "date", Description: "only list the posts published after a date or a duration ago"},
+			{Name: "until", Type: handlers.StringFlag, Value: "date", Description: "only list the posts published before a date or a duration ago"},
+			{Name: "offset", Type: handlers.IntFlag, Value: "n", Description: "number of posts to skip"},
+			{Name: "page", Type: handlers.StringFlag, Value: "cursor", Description: "list the posts after the cursor printed by the previous page"},
+			{Name: "sort", Type: handlers.StringFlag, Value: "newest|oldest", Description: "order of the posts, newest by default",
+				Complete: handlers.Values("newest", "oldest")},
+		},
+		Handler: middlewares.LoggedIn(handlers.Browse),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "read",
+		Description: "Mark a post, by ID or URL, as read",
+		Args:        []handlers.Arg{{Name: "post"}},
+		Handler:     middlewares.LoggedIn(handlers.Read),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "unread",
+		Description: "Mark a post, by ID or URL, as unread",
+		Args:        []handlers.Arg{{Name: "post"}},
+		Handler:     middlewares.LoggedIn(handlers.Unread),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "mark-all-read",
+		Aliases:     []string{"read-all"},
+		Description: "Mark all the posts of the followed feeds, or of one feed, as read",
+		Args:        []handlers.Arg{{Name: "feed url", Optional: true, Complete: handlers.CompleteFollowedFeedURLs}},
+		Handler:     middlewares.LoggedIn(handlers.MarkAllRead),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "star",
+		Description: "Star a post, by ID or URL, to keep it even if its feed is deleted",
+		Args:        []handlers.Arg{{Name: "post"}},
+		Handler:     middlewares.LoggedIn(handlers.Star),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "unstar",
+		Description: "Unstar a post, by ID or URL",
+		Args:        []handlers.Arg{{Name: "post"}},
+		Handler:     middlewares.LoggedIn(handlers.Unstar),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "starred",
+		Description: "List the posts starred by the user",
+		Handler:     middlewares.LoggedIn(handlers.Starred),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "search",
+		Aliases:     []string{"find"},
+		Description: "Search the posts of the feeds followed by the user",
+		Args:        []handlers.Arg{{Name: "query", Variadic: true}},
+		Flags: []handlers.Flag{
+			{Name: "feed", Type: handlers.StringFlag, Value: "url", Description: "only search the posts of a feed",
+				Complete: handlers.CompleteFollowedFeedURLs},
+			{Name: "since", Type: handlers.StringFlag, Value: "date", Description: "only search the posts published after a date or a duration ago"},
+			{Name: "limit", Type: handlers.IntFlag, Value: "n", Description: "number of posts to list, 10 by default"},
+		},
+		Handler: middlewares.LoggedIn(handlers.Search),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "publish",
+		Description: "Print the latest posts of the feeds followed by a user as a single feed",
+		Flags: []handlers.Flag{
+			{Name: "user", Type: handlers.StringFlag, Value: "name", Description: "user whose timeline is published, the current one by default",
+				Complete: handlers.CompleteUsernames},
+			{Name: "format", Type: handlers.StringFlag, Value: "rss|atom|json", Description: "format of the feed, rss by default",
+				Complete: handlers.Values("rss", "atom", "json")},
+			{Name: "limit", Type: handlers.IntFlag, Value: "n", Description: "number of posts to publish, 50 by default"},
+			{Name: "base-url", Type: handlers.StringFlag, Value: "url", Description: "URL gator is served at, http://localhost:8080 by default"},
+		},
+		Handler: handlers.Publish,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "serve",
+		Description: "Serve the web reader of the user, the JSON API and the sync APIs over HTTP until interrupted",
+		Flags: []handlers.Flag{
+			{Name: "addr", Type: handlers.StringFlag, Value: "host:port", Description: "address to listen on, localhost:8080 by default"},
+		},
+		Handler: handlers.Serve,
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "api-password",
+		Description: "Set the password the current user signs in to the sync APIs with, read from stdin",
+		Handler:     middlewares.LoggedIn(handlers.ApiPassword),
+	})
+	cmds.Register(handlers.Spec{
+		Name:        "fever-password",
+		Description: "Generate the password the current user signs in to the Fever API with, replacing the previous one",
+		Handler:     middlewares.LoggedIn(handlers.FeverPassword),
+	})
+}
@@ -0,0 +1,253 @@
+// Package api serves a versioned JSON REST API over the gator database.
+package api
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"gator/internal/auth"
+	"gator/internal/database"
+	"gator/internal/feeds"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Store is the database the API reads and writes. It is an interface so that
+// the server can run against a fake in tests.
+type Store interface {
+	database.Querier
+	// InTx runs fn with queries bound to a transaction, which is committed
+	// when fn succeeds and rolled back otherwise.
+	InTx(ctx context.Context, fn func(q database.Querier) error) error
+}
+
+// DBStore is the Store backed by Postgres.
+type DBStore struct {
+	*database.Queries
+	db *sql.DB
+}
+
+func NewDBStore(db *sql.DB) *DBStore {
+	return &DBStore{Queries: database.New(db), db: db}
+}
+
+func (s *DBStore) InTx(ctx context.Context, fn func(q database.Querier) error) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(s.Queries.WithTx(tx)); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+type Server struct {
+	store Store
+	mux   *http.ServeMux
+}
+
+// NewServer routes the version 1 of the API under /api/v1. The routes that
+// change data require the credentials of a user, see Authenticate.
+func NewServer(store Store) *Server {
+	srv := &Server{store: store, mux: http.NewServeMux()}
+
+	srv.mux.HandleFunc("GET /api/v1/feeds", srv.listFeeds)
+	srv.mux.HandleFunc("POST /api/v1/feeds", srv.authed(srv.addFeed))
+	srv.mux.HandleFunc("GET /api/v1/feeds/{id}", srv.getFeed)
+	srv.mux.HandleFunc("DELETE /api/v1/feeds/{id}", srv.authed(srv.deleteFeed))
+	srv.mux.HandleFunc("GET /api/v1/users/{user}/follows", srv.listFollows)
+	srv.mux.HandleFunc("POST /api/v1/users/{user}/follows", srv.authed(srv.follow))
+	srv.mux.HandleFunc("DELETE /api/v1/users/{user}/follows/{feed}", srv.authed(srv.unfollow))
+	srv.mux.HandleFunc("GET /api/v1/users/{user}/posts", srv.listPosts)
+	srv.mux.HandleFunc(catchAll, srv.noRoute)
+
+	return srv
+}
+
+const catchAll = "/api/"
+
+// noRoute answers the requests that no route matches: 405 with the allowed
+// methods when the path has routes for other methods, 404 otherwise.
+func (srv *Server) noRoute(w http.ResponseWriter, r *http.Request) {
+	allowed := []string{}
+	for _, method := range []string{"GET", "HEAD", "POST", "PUT", "PATCH", "DELETE"} {
+		probe := r.Clone(r.Context())
+		probe.Method = method
+		if _, pattern := srv.mux.Handler(probe); pattern != catchAll {
+			allowed = append(allowed, method)
+		}
+	}
+
+	if len(allowed) == 0 {
+		WriteError(w, r, &Error{Status: http.StatusNotFound, Message: "no such endpoint"})
+		return
+	}
+
+	w.Header().Set("Allow", strings.Join(allowed, ", "))
+	WriteError(w, r, &Error{Status: http.StatusMethodNotAllowed, Message: fmt.Sprintf("method %s is not allowed", r.Method)})
+}
+
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mux.ServeHTTP(w, r)
+}
+
+// Authenticate checks the HTTP Basic credentials of a request: the name of a
+// user and the API password set with 'gator api-password'.
+func Authenticate(ctx context.Context, store Store, r *http.Request) (database.User, error) {
+	unauthorized := &Error{Status: http.StatusUnauthorized, Message: "a user name and API password are required"}
+
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return database.User{}, unauthorized
+	}
+
+	user, err := store.GetUser(ctx, username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, unauthorized
+	}
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to get user '%s': %w", username, err)
+	}
+
+	credential, err := store.GetApiCredential(ctx, user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		return database.User{}, unauthorized
+	}
+	if err != nil {
+		return database.User{}, fmt.Errorf("failed to get API credential of user '%s': %w", user.Name, err)
+	}
+
+	if !auth.CheckPassword(credential.PasswordHash, password) {
+		return database.User{}, unauthorized
+	}
+
+	return user, nil
+}
+
+// authed authenticates the requests of a handler that changes data.
+func (srv *Server) authed(handler func(w http.ResponseWriter, r *http.Request, user database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := Authenticate(r.Context(), srv.store, r)
+		if err != nil {
+			WriteError(w, r, err)
+			return
+		}
+
+		handler(w, r, user)
+	}
+}
+
+// Error is an error with the HTTP status it is reported with.
+type Error struct {
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// BadRequest returns an error reported with the 400 status.
+func BadRequest(format string, a ...any) *Error {
+	return &Error{Status: http.StatusBadRequest, Message: fmt.Sprintf(format, a...)}
+}
+
+func forbidden(format string, a ...any) *Error {
+	return &Error{Status: http.StatusForbidden, Message: fmt.Sprintf(format, a...)}
+}
+
+// errorEnvelope is the body of every error response.
+type errorEnvelope struct {
+	Error struct {
+		Status  int    `json:"status"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// WriteError maps err to a status and writes it in the error envelope. The
+// details of unexpected errors are logged rather than sent to the client.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	apiErr := &Error{}
+	pqErr := &pq.Error{}
+
+	switch {
+	case errors.As(err, &apiErr):
+	case errors.Is(err, feeds.ErrInvalidURL):
+		apiErr = BadRequest("%v", err)
+	case errors.Is(err, sql.ErrNoRows):
+		apiErr = &Error{Status: http.StatusNotFound, Message: "not found"}
+	case errors.As(err, &pqErr) && pqErr.Code == "23505":
+		apiErr = &Error{Status: http.StatusConflict, Message: "already exists"}
+	default:
+		log.Printf("error handling %s %s: %v", r.Method, r.URL.Path, err)
+		apiErr = &Error{Status: http.StatusInternalServerError, Message: "internal server error"}
+	}
+
+	if apiErr.Status == http.StatusUnauthorized {
+		w.Header().Set("WWW-Authenticate", `Basic realm="gator"`)
+	}
+
+	envelope := errorEnvelope{}
+	envelope.Error.Status = apiErr.Status
+	envelope.Error.Message = apiErr.Message
+
+	WriteJSON(w, apiErr.Status, envelope)
+}
+
+// WriteJSON writes v as the JSON body of a response with the given status.
+func WriteJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to encode response: %v", err)
+	}
+}
+
+// readJSON decodes the request body into v, rejecting unknown fields.
+func readJSON(r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(nil, r.Body, 1<<20))
+	decoder.DisallowUnknownFields()
+
+	if err := decoder.Decode(v); err != nil {
+		return BadRequest("invalid request body: %v", err)
+	}
+
+	return nil
+}
+
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// LogRequests logs the method, path, status and duration of every request.
+func LogRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		recorder := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(recorder, r)
+
+		log.Printf("%s %s %d %s", r.Method, r.URL.Path, recorder.status, time.Since(start).Round(time.Microsecond))
+	})
+}
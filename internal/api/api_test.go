@@ -0,0 +1,493 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"crypto/pbkdf2"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"gator/internal/database"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+const password = "correct horse"
+
+// passwordHash hashes password like auth.HashPassword, with a single
+// iteration to keep the tests fast.
+func passwordHash(t *testing.T) string {
+	t.Helper()
+
+	salt := []byte("0123456789abcdef")
+	key, err := pbkdf2.Key(sha256.New, password, salt, 1, 32)
+	if err != nil {
+		t.Fatalf("pbkdf2.Key() error = %v", err)
+	}
+
+	return "pbkdf2-sha256$1$" + base64.RawStdEncoding.EncodeToString(salt) + "$" + base64.RawStdEncoding.EncodeToString(key)
+}
+
+// fakeStore keeps users, feeds, follows and posts in memory. The queries
+// the tests do not use panic on the nil Querier.
+type fakeStore struct {
+	database.Querier
+	users       []database.User
+	credentials map[uuid.UUID]database.ApiCredential
+	feeds       []database.Feed
+	follows     []database.CreateFeedFollowParams
+	posts       []database.GetNewestPostsForUserRow
+}
+
+func newFakeStore(t *testing.T) *fakeStore {
+	alice := database.User{ID: uuid.New(), Name: "alice"}
+	bob := database.User{ID: uuid.New(), Name: "bob"}
+
+	return &fakeStore{
+		users: []database.User{alice, bob},
+		credentials: map[uuid.UUID]database.ApiCredential{
+			alice.ID: {UserID: alice.ID, PasswordHash: passwordHash(t)},
+			bob.ID:   {UserID: bob.ID, PasswordHash: passwordHash(t)},
+		},
+	}
+}
+
+func (s *fakeStore) user(name string) database.User {
+	for _, user := range s.users {
+		if user.Name == name {
+			return user
+		}
+	}
+	return database.User{}
+}
+
+func (s *fakeStore) InTx(ctx context.Context, fn func(q database.Querier) error) error {
+	return fn(s)
+}
+
+func (s *fakeStore) GetUser(ctx context.Context, name string) (database.User, error) {
+	for _, user := range s.users {
+		if user.Name == name {
+			return user, nil
+		}
+	}
+	return database.User{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) GetApiCredential(ctx context.Context, userID uuid.UUID) (database.ApiCredential, error) {
+	credential, ok := s.credentials[userID]
+	if !ok {
+		return credential, sql.ErrNoRows
+	}
+	return credential, nil
+}
+
+func (s *fakeStore) GetAllFeedsWithUsers(ctx context.Context) ([]database.GetAllFeedsWithUsersRow, error) {
+	rows := []database.GetAllFeedsWithUsersRow{}
+	for _, feed := range s.feeds {
+		for _, user := range s.users {
+			if user.ID == feed.UserID {
+				rows = append(rows, database.GetAllFeedsWithUsersRow{Feed: feed, User: user})
+			}
+		}
+	}
+	return rows, nil
+}
+
+func (s *fakeStore) GetFeedByID(ctx context.Context, id uuid.UUID) (database.Feed, error) {
+	for _, feed := range s.feeds {
+		if feed.ID == id {
+			return feed, nil
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) GetFeedByUrl(ctx context.Context, url string) (database.Feed, error) {
+	for _, feed := range s.feeds {
+		if feed.Url == url {
+			return feed, nil
+		}
+	}
+	return database.Feed{}, sql.ErrNoRows
+}
+
+func (s *fakeStore) CreateFeed(ctx context.Context, arg database.CreateFeedParams) (database.Feed, error) {
+	if _, err := s.GetFeedByUrl(ctx, arg.Url); err == nil {
+		return database.Feed{}, &pq.Error{Code: "23505"}
+	}
+
+	feed := database.Feed{ID: uuid.New(), Name: arg.Name, Url: arg.Url, UserID: arg.UserID}
+	s.feeds = append(s.feeds, feed)
+	return feed, nil
+}
+
+func (s *fakeStore) DeleteFeed(ctx context.Context, id uuid.UUID) (int64, error) {
+	for i, feed := range s.feeds {
+		if feed.ID == id {
+			s.feeds = append(s.feeds[:i], s.feeds[i+1:]...)
+			return 1, nil
+		}
+	}
+	return 0, nil
+}
+
+func (s *fakeStore) CreateFeedFollow(ctx context.Context, arg database.CreateFeedFollowParams) (database.CreateFeedFollowRow, error) {
+	for _, follow := range s.follows {
+		if follow.FeedID == arg.FeedID && follow.UserID == arg.UserID {
+			return database.CreateFeedFollowRow{}, &pq.Error{Code: "23505"}
+		}
+	}
+
+	feed, err := s.GetFeedByID(ctx, arg.FeedID)
+	if err != nil {
+		return database.CreateFeedFollowRow{}, err
+	}
+
+	s.follows = append(s.follows, arg)
+	return database.CreateFeedFollowRow{FeedID: arg.FeedID, UserID: arg.UserID, Category: arg.Category, Feed: feed}, nil
+}
+
+func (s *fakeStore) DeleteFeedFollow(ctx context.Context, arg database.DeleteFeedFollowParams) error {
+	for i, follow := range s.follows {
+		if follow.FeedID == arg.FeedID && follow.UserID == arg.UserID {
+			s.follows = append(s.follows[:i], s.follows[i+1:]...)
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) GetFeedFollowsByUser(ctx context.Context, userID uuid.UUID) ([]database.GetFeedFollowsByUserRow, error) {
+	rows := []database.GetFeedFollowsByUserRow{}
+	for _, follow := range s.follows {
+		if follow.UserID == userID {
+			feed, _ := s.GetFeedByID(ctx, follow.FeedID)
+			rows = append(rows, database.GetFeedFollowsByUserRow{Feed: feed, Category: follow.Category})
+		}
+	}
+	return rows, nil
+}
+
+// GetNewestPostsForUser pages through the posts, which are sorted newest
+// first, by their (published_at, id) key like the query.
+func (s *fakeStore) GetNewestPostsForUser(ctx context.Context, arg database.GetNewestPostsForUserParams) ([]database.GetNewestPostsForUserRow, error) {
+	rows := []database.GetNewestPostsForUserRow{}
+	for _, row := range s.posts {
+		post := row.Post
+		before := post.PublishedAt.Before(arg.CursorPublishedAt) ||
+			(post.PublishedAt.Equal(arg.CursorPublishedAt) && bytes.Compare(post.ID[:], arg.CursorID[:]) < 0)
+		if before && len(rows) < int(arg.Limit) {
+			rows = append(rows, row)
+		}
+	}
+	return rows, nil
+}
+
+type response struct {
+	status int
+	header http.Header
+	body   []byte
+}
+
+// do sends a request to a server over the store. The request is signed in
+// as user when it is set.
+func do(t *testing.T, store Store, method string, target string, body string, user string) response {
+	t.Helper()
+
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if user != "" {
+		r.SetBasicAuth(user, password)
+	}
+
+	w := httptest.NewRecorder()
+	NewServer(store).ServeHTTP(w, r)
+
+	return response{status: w.Code, header: w.Header(), body: w.Body.Bytes()}
+}
+
+func decode[T any](t *testing.T, res response) T {
+	t.Helper()
+
+	var v T
+	if err := json.Unmarshal(res.body, &v); err != nil {
+		t.Fatalf("failed to decode %s: %v", res.body, err)
+	}
+	return v
+}
+
+// checkError checks the status and the error envelope of a response.
+func checkError(t *testing.T, res response, status int) {
+	t.Helper()
+
+	if res.status != status {
+		t.Fatalf("status = %d, want %d: %s", res.status, status, res.body)
+	}
+
+	envelope := decode[errorEnvelope](t, res)
+	if envelope.Error.Status != status || envelope.Error.Message == "" {
+		t.Errorf("error envelope = %+v, want status %d and a message", envelope.Error, status)
+	}
+}
+
+func TestListFeeds(t *testing.T) {
+	store := newFakeStore(t)
+	store.feeds = []database.Feed{{ID: uuid.New(), Name: "Blog", Url: "https://example.com/feed", UserID: store.user("alice").ID}}
+
+	res := do(t, store, "GET", "/api/v1/feeds", "", "")
+	if res.status != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", res.status, res.body)
+	}
+
+	feeds := decode[[]Feed](t, res)
+	if len(feeds) != 1 || feeds[0].Name != "Blog" || feeds[0].User != "alice" {
+		t.Errorf("feeds = %+v, want the feed of alice", feeds)
+	}
+}
+
+func TestAddFeed(t *testing.T) {
+	store := newFakeStore(t)
+
+	res := do(t, store, "POST", "/api/v1/feeds", `{"name": "Blog", "url": " https://example.com/feed "}`, "alice")
+	if res.status != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", res.status, res.body)
+	}
+
+	feed := decode[Feed](t, res)
+	if feed.Name != "Blog" || feed.URL != "https://example.com/feed" || feed.User != "alice" {
+		t.Errorf("feed = %+v, want Blog at https://example.com/feed added by alice", feed)
+	}
+	if len(store.follows) != 1 || store.follows[0].UserID != store.user("alice").ID {
+		t.Errorf("follows = %+v, want alice to follow the feed", store.follows)
+	}
+
+	// The same URL cannot be added twice
+	res = do(t, store, "POST", "/api/v1/feeds", `{"name": "Again", "url": "https://example.com/feed"}`, "alice")
+	checkError(t, res, http.StatusConflict)
+}
+
+func TestAddFeedErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		body   string
+		user   string
+		status int
+	}{
+		{"no credentials", `{"name": "Blog", "url": "https://example.com/feed"}`, "", http.StatusUnauthorized},
+		{"unknown user", `{"name": "Blog", "url": "https://example.com/feed"}`, "carol", http.StatusUnauthorized},
+		{"other user", `{"name": "Blog", "url": "https://example.com/feed", "user": "bob"}`, "alice", http.StatusForbidden},
+		{"no name", `{"url": "https://example.com/feed"}`, "alice", http.StatusBadRequest},
+		{"invalid URL", `{"name": "Blog", "url": "ftp://example.com/feed"}`, "alice", http.StatusBadRequest},
+		{"unknown field", `{"name": "Blog", "url": "https://example.com/feed", "color": "red"}`, "alice", http.StatusBadRequest},
+		{"invalid JSON", `{"name": `, "alice", http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newFakeStore(t)
+
+			res := do(t, store, "POST", "/api/v1/feeds", tt.body, tt.user)
+			checkError(t, res, tt.status)
+
+			if len(store.feeds) != 0 {
+				t.Errorf("feeds = %+v, want none", store.feeds)
+			}
+		})
+	}
+}
+
+func TestAddFeedWrongPassword(t *testing.T) {
+	store := newFakeStore(t)
+
+	r := httptest.NewRequest("POST", "/api/v1/feeds", strings.NewReader(`{"name": "Blog", "url": "https://example.com/feed"}`))
+	r.SetBasicAuth("alice", "wrong")
+	w := httptest.NewRecorder()
+	NewServer(store).ServeHTTP(w, r)
+
+	res := response{status: w.Code, header: w.Header(), body: w.Body.Bytes()}
+	checkError(t, res, http.StatusUnauthorized)
+	if res.header.Get("WWW-Authenticate") == "" {
+		t.Error("WWW-Authenticate header is not set")
+	}
+}
+
+func TestDeleteFeed(t *testing.T) {
+	store := newFakeStore(t)
+	feed := database.Feed{ID: uuid.New(), Name: "Blog", Url: "https://example.com/feed", UserID: store.user("alice").ID}
+	store.feeds = []database.Feed{feed}
+
+	res := do(t, store, "DELETE", "/api/v1/feeds/"+feed.ID.String(), "", "bob")
+	checkError(t, res, http.StatusForbidden)
+
+	res = do(t, store, "DELETE", "/api/v1/feeds/"+feed.ID.String(), "", "alice")
+	if res.status != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204: %s", res.status, res.body)
+	}
+	if len(store.feeds) != 0 {
+		t.Errorf("feeds = %+v, want none", store.feeds)
+	}
+
+	res = do(t, store, "DELETE", "/api/v1/feeds/"+feed.ID.String(), "", "alice")
+	checkError(t, res, http.StatusNotFound)
+
+	res = do(t, store, "DELETE", "/api/v1/feeds/not-a-uuid", "", "alice")
+	checkError(t, res, http.StatusBadRequest)
+}
+
+func TestFollowAndUnfollow(t *testing.T) {
+	store := newFakeStore(t)
+	feed := database.Feed{ID: uuid.New(), Name: "Blog", Url: "https://example.com/feed", UserID: store.user("alice").ID}
+	store.feeds = []database.Feed{feed}
+
+	res := do(t, store, "POST", "/api/v1/users/bob/follows", `{"url": "https://example.com/feed", "category": "Tech"}`, "alice")
+	checkError(t, res, http.StatusForbidden)
+
+	res = do(t, store, "POST", "/api/v1/users/bob/follows", `{"url": "https://example.com/feed", "category": "Tech"}`, "bob")
+	if res.status != http.StatusCreated {
+		t.Fatalf("status = %d, want 201: %s", res.status, res.body)
+	}
+	if followed := decode[Feed](t, res); followed.ID != feed.ID || followed.Category != "Tech" {
+		t.Errorf("followed = %+v, want the feed in Tech", followed)
+	}
+
+	res = do(t, store, "POST", "/api/v1/users/bob/follows", `{"url": "https://example.com/feed"}`, "bob")
+	checkError(t, res, http.StatusConflict)
+
+	res = do(t, store, "POST", "/api/v1/users/bob/follows", `{"url": "https://example.com/other"}`, "bob")
+	checkError(t, res, http.StatusNotFound)
+
+	res = do(t, store, "GET", "/api/v1/users/bob/follows", "", "")
+	if follows := decode[[]Feed](t, res); len(follows) != 1 || follows[0].ID != feed.ID {
+		t.Errorf("follows = %+v, want the feed", follows)
+	}
+
+	res = do(t, store, "DELETE", "/api/v1/users/bob/follows/"+feed.ID.String(), "", "")
+	checkError(t, res, http.StatusUnauthorized)
+
+	res = do(t, store, "DELETE", "/api/v1/users/bob/follows/"+feed.ID.String(), "", "bob")
+	if res.status != http.StatusNoContent {
+		t.Fatalf("status = %d, want 204: %s", res.status, res.body)
+	}
+	if len(store.follows) != 0 {
+		t.Errorf("follows = %+v, want none", store.follows)
+	}
+}
+
+func TestListPosts(t *testing.T) {
+	store := newFakeStore(t)
+	alice := store.user("alice")
+
+	// Two posts share a date, so that the page boundary falls between them
+	start := time.Date(2026, time.October, 18, 12, 0, 0, 0, time.UTC)
+	dates := []time.Time{start, start.Add(-time.Hour), start.Add(-time.Hour), start.Add(-2 * time.Hour), start.Add(-3 * time.Hour)}
+	for i, date := range dates {
+		id := uuid.UUID{15: byte(len(dates) - i)}
+		store.posts = append(store.posts, database.GetNewestPostsForUserRow{
+			Post: database.Post{ID: id, Title: "Post", PublishedAt: date},
+			User: alice,
+		})
+	}
+
+	seen := []uuid.UUID{}
+	target := "/api/v1/users/alice/posts?limit=2"
+	for pages := 0; target != ""; pages++ {
+		if pages > len(dates) {
+			t.Fatalf("pagination does not end")
+		}
+
+		res := do(t, store, "GET", target, "", "")
+		if res.status != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", res.status, res.body)
+		}
+
+		page := decode[PostPage](t, res)
+		for _, post := range page.Posts {
+			seen = append(seen, post.ID)
+		}
+
+		target = ""
+		if page.NextPage != "" {
+			target = "/api/v1/users/alice/posts?limit=2&page=" + page.NextPage
+		}
+	}
+
+	if len(seen) != len(store.posts) {
+		t.Fatalf("seen %d posts, want %d", len(seen), len(store.posts))
+	}
+	for i, row := range store.posts {
+		if seen[i] != row.Post.ID {
+			t.Errorf("post %d = %s, want %s", i, seen[i], row.Post.ID)
+		}
+	}
+}
+
+func TestListPostsErrors(t *testing.T) {
+	store := newFakeStore(t)
+
+	tests := []struct {
+		name   string
+		target string
+		status int
+	}{
+		{"unknown user", "/api/v1/users/carol/posts", http.StatusNotFound},
+		{"invalid limit", "/api/v1/users/alice/posts?limit=0", http.StatusBadRequest},
+		{"invalid page", "/api/v1/users/alice/posts?page=nope", http.StatusBadRequest},
+		{"invalid feed", "/api/v1/users/alice/posts?feed=nope", http.StatusBadRequest},
+		{"invalid since", "/api/v1/users/alice/posts?since=yesterday", http.StatusBadRequest},
+		{"invalid sort", "/api/v1/users/alice/posts?sort=random", http.StatusBadRequest},
+		{"unknown endpoint", "/api/v1/nope", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			checkError(t, do(t, store, "GET", tt.target, "", ""), tt.status)
+		})
+	}
+}
+
+func TestMethodNotAllowed(t *testing.T) {
+	store := newFakeStore(t)
+
+	tests := []struct {
+		method string
+		target string
+		allow  string
+	}{
+		{"PUT", "/api/v1/feeds", "GET, HEAD, POST"},
+		{"POST", "/api/v1/feeds/" + uuid.New().String(), "GET, HEAD, DELETE"},
+		{"DELETE", "/api/v1/users/alice/posts", "GET, HEAD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.target, func(t *testing.T) {
+			res := do(t, store, tt.method, tt.target, "", "alice")
+			checkError(t, res, http.StatusMethodNotAllowed)
+
+			if allow := res.header.Get("Allow"); allow != tt.allow {
+				t.Errorf("Allow = %q, want %q", allow, tt.allow)
+			}
+		})
+	}
+}
+
+func TestLogRequests(t *testing.T) {
+	defer log.SetOutput(log.Writer())
+	output := &bytes.Buffer{}
+	log.SetOutput(output)
+
+	handler := LogRequests(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/v1/feeds", nil))
+
+	if line := output.String(); !strings.Contains(line, "GET /api/v1/feeds 418") {
+		t.Errorf("log = %q, want the method, path and status of the request", line)
+	}
+}
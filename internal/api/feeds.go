@@ -0,0 +1,257 @@
+package api
+
+import (
+	"database/sql"
+	"gator/internal/database"
+	"gator/internal/feeds"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Feed struct {
+	ID                  uuid.UUID  `json:"id"`
+	Name                string     `json:"name"`
+	URL                 string     `json:"url"`
+	Link                string     `json:"link,omitempty"`
+	User                string     `json:"user,omitempty"`
+	Category            string     `json:"category,omitempty"`
+	LastFetchedAt       *time.Time `json:"last_fetched_at,omitempty"`
+	NextFetchAt         time.Time  `json:"next_fetch_at"`
+	LastError           string     `json:"last_error,omitempty"`
+	ConsecutiveFailures int32      `json:"consecutive_failures"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+func newFeed(feed database.Feed) Feed {
+	return Feed{
+		ID:                  feed.ID,
+		Name:                feed.Name,
+		URL:                 feed.Url,
+		Link:                feed.Link,
+		LastFetchedAt:       nullTime(feed.LastFetchedAt),
+		NextFetchAt:         feed.NextFetchAt,
+		LastError:           feed.LastError,
+		ConsecutiveFailures: feed.ConsecutiveFailures,
+		CreatedAt:           feed.CreatedAt,
+		UpdatedAt:           feed.UpdatedAt,
+	}
+}
+
+func nullTime(t sql.NullTime) *time.Time {
+	if !t.Valid {
+		return nil
+	}
+	return &t.Time
+}
+
+func (srv *Server) listFeeds(w http.ResponseWriter, r *http.Request) {
+	rows, err := srv.store.GetAllFeedsWithUsers(r.Context())
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	feeds := []Feed{}
+	for _, row := range rows {
+		feed := newFeed(row.Feed)
+		feed.User = row.User.Name
+		feeds = append(feeds, feed)
+	}
+
+	WriteJSON(w, http.StatusOK, feeds)
+}
+
+func (srv *Server) getFeed(w http.ResponseWriter, r *http.Request) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	feed, err := srv.store.GetFeedByID(r.Context(), id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	WriteJSON(w, http.StatusOK, newFeed(feed))
+}
+
+type addFeedRequest struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+	User string `json:"user"`
+}
+
+// addFeed creates a feed and makes the authenticated user follow it, like
+// 'gator addfeed'. The user of the request, when set, must be that user.
+func (srv *Server) addFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	request := addFeedRequest{}
+	if err := readJSON(r, &request); err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	if request.Name == "" {
+		WriteError(w, r, BadRequest("name is required"))
+		return
+	}
+	if request.User != "" && request.User != user.Name {
+		WriteError(w, r, forbidden("cannot add a feed for user '%s'", request.User))
+		return
+	}
+
+	var created database.Feed
+	err := srv.store.InTx(r.Context(), func(q database.Querier) error {
+		var err error
+		created, err = feeds.Add(r.Context(), q, user, request.Name, request.URL)
+		return err
+	})
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	feed := newFeed(created)
+	feed.User = user.Name
+
+	WriteJSON(w, http.StatusCreated, feed)
+}
+
+// deleteFeed deletes a feed added by the authenticated user.
+func (srv *Server) deleteFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	id, err := pathID(r, "id")
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	feed, err := srv.store.GetFeedByID(r.Context(), id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	if feed.UserID != user.ID {
+		WriteError(w, r, forbidden("feed '%s' was added by another user", feed.Name))
+		return
+	}
+
+	count, err := srv.store.DeleteFeed(r.Context(), id)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	if count == 0 {
+		WriteError(w, r, sql.ErrNoRows)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (srv *Server) listFollows(w http.ResponseWriter, r *http.Request) {
+	user, err := srv.store.GetUser(r.Context(), r.PathValue("user"))
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	rows, err := srv.store.GetFeedFollowsByUser(r.Context(), user.ID)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	feeds := []Feed{}
+	for _, row := range rows {
+		feed := newFeed(row.Feed)
+		feed.Category = row.Category
+		feeds = append(feeds, feed)
+	}
+
+	WriteJSON(w, http.StatusOK, feeds)
+}
+
+type followRequest struct {
+	URL      string `json:"url"`
+	Category string `json:"category"`
+}
+
+func (srv *Server) follow(w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := checkPathUser(r, user); err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	request := followRequest{}
+	if err := readJSON(r, &request); err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	feed, err := srv.store.GetFeedByUrl(r.Context(), request.URL)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	row, err := srv.store.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		FeedID:   feed.ID,
+		UserID:   user.ID,
+		Category: request.Category,
+	})
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	followed := newFeed(row.Feed)
+	followed.Category = row.Category
+
+	WriteJSON(w, http.StatusCreated, followed)
+}
+
+func (srv *Server) unfollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := checkPathUser(r, user); err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	feedID, err := pathID(r, "feed")
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	err = srv.store.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
+		FeedID: feedID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func pathID(r *http.Request, name string) (uuid.UUID, error) {
+	id, err := uuid.Parse(r.PathValue(name))
+	if err != nil {
+		return id, BadRequest("invalid %s '%s': must be a UUID", name, r.PathValue(name))
+	}
+
+	return id, nil
+}
+
+// checkPathUser checks that the user of the path is the authenticated one.
+func checkPathUser(r *http.Request, user database.User) error {
+	if r.PathValue("user") != user.Name {
+		return forbidden("cannot change the follows of user '%s'", r.PathValue("user"))
+	}
+
+	return nil
+}
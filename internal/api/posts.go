@@ -0,0 +1,146 @@
+package api
+
+import (
+	"database/sql"
+	"gator/internal/cursor"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	defaultPostsLimit = 20
+	maxPostsLimit     = 100
+)
+
+type Post struct {
+	ID                   uuid.UUID  `json:"id"`
+	FeedID               *uuid.UUID `json:"feed_id"`
+	Title                string     `json:"title"`
+	URL                  string     `json:"url"`
+	Description          string     `json:"description"`
+	Author               string     `json:"author,omitempty"`
+	PublishedAt          time.Time  `json:"published_at"`
+	PublishedAtEstimated bool       `json:"published_at_estimated"`
+	Read                 bool       `json:"read"`
+	Starred              bool       `json:"starred"`
+}
+
+// PostPage is a page of posts. NextPage is the cursor of the next page, set
+// when the page is full.
+type PostPage struct {
+	Posts    []Post `json:"posts"`
+	NextPage string `json:"next_page,omitempty"`
+}
+
+// listPosts pages through the posts of the feeds followed by a user, like
+// 'gator browse'. The query parameters are limit, page, feed, since, until,
+// sort and all.
+func (srv *Server) listPosts(w http.ResponseWriter, r *http.Request) {
+	user, err := srv.store.GetUser(r.Context(), r.PathValue("user"))
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	params, err := postsParams(r)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+	params.ID = user.ID
+
+	rows, err := cursor.Posts(r.Context(), srv.store, params)
+	if err != nil {
+		WriteError(w, r, err)
+		return
+	}
+
+	page := PostPage{Posts: []Post{}}
+	for _, row := range rows {
+		post := Post{
+			ID:                   row.Post.ID,
+			Title:                row.Post.Title,
+			URL:                  row.Post.Url,
+			Description:          row.Post.Description,
+			Author:               row.Post.Author,
+			PublishedAt:          row.Post.PublishedAt,
+			PublishedAtEstimated: row.Post.PublishedAtEstimated,
+			Read:                 row.Read,
+			Starred:              row.Starred,
+		}
+		if row.Post.FeedID.Valid {
+			post.FeedID = &row.Post.FeedID.UUID
+		}
+		page.Posts = append(page.Posts, post)
+	}
+
+	if len(rows) == int(params.Limit) {
+		last := rows[len(rows)-1].Post
+		page.NextPage = cursor.Encode(last.PublishedAt, last.ID)
+	}
+
+	WriteJSON(w, http.StatusOK, page)
+}
+
+func postsParams(r *http.Request) (cursor.PostsParams, error) {
+	query := r.URL.Query()
+
+	params := cursor.PostsParams{
+		Limit:       defaultPostsLimit,
+		IncludeRead: query.Get("all") == "true",
+	}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > maxPostsLimit {
+			return params, BadRequest("invalid limit '%s': must be an integer between 1 and %d", value, maxPostsLimit)
+		}
+		params.Limit = int32(limit)
+	}
+
+	if value := query.Get("page"); value != "" {
+		publishedAt, id, err := cursor.Decode(value)
+		if err != nil {
+			return params, BadRequest("%v", err)
+		}
+		params.CursorPublishedAt = sql.NullTime{Time: publishedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	if value := query.Get("feed"); value != "" {
+		id, err := uuid.Parse(value)
+		if err != nil {
+			return params, BadRequest("invalid feed '%s': must be a UUID", value)
+		}
+		params.FeedID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	if value := query.Get("since"); value != "" {
+		since, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return params, BadRequest("invalid since '%s': must be an RFC 3339 date", value)
+		}
+		params.Since = sql.NullTime{Time: since.UTC(), Valid: true}
+	}
+
+	if value := query.Get("until"); value != "" {
+		until, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return params, BadRequest("invalid until '%s': must be an RFC 3339 date", value)
+		}
+		params.Until = sql.NullTime{Time: until.UTC(), Valid: true}
+	}
+
+	switch value := query.Get("sort"); value {
+	case "", "newest":
+	case "oldest":
+		params.OldestFirst = true
+	default:
+		return params, BadRequest("invalid sort '%s': must be 'newest' or 'oldest'", value)
+	}
+
+	return params, nil
+}
@@ -0,0 +1,90 @@
+// Package auth hashes and checks the passwords the sync APIs authenticate
+// users with.
+package auth
+
+import (
+	"crypto/hmac"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const (
+	scheme     = "pbkdf2-sha256"
+	iterations = 600000
+	saltLength = 16
+	keyLength  = 32
+)
+
+// HashPassword hashes a password with PBKDF2 and a random salt. The hash is
+// stored as scheme$iterations$salt$key.
+func HashPassword(password string) (string, error) {
+	salt := make([]byte, saltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("failed to generate salt: %w", err)
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, iterations, keyLength)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash password: %w", err)
+	}
+
+	return strings.Join([]string{
+		scheme,
+		strconv.Itoa(iterations),
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key),
+	}, "$"), nil
+}
+
+// CheckPassword reports whether a password matches a hash made by
+// HashPassword.
+func CheckPassword(hash string, password string) bool {
+	parts := strings.Split(hash, "$")
+	if len(parts) != 4 || parts[0] != scheme {
+		return false
+	}
+
+	iter, err := strconv.Atoi(parts[1])
+	if err != nil || iter < 1 {
+		return false
+	}
+
+	salt, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return false
+	}
+
+	want, err := base64.RawStdEncoding.DecodeString(parts[3])
+	if err != nil {
+		return false
+	}
+
+	key, err := pbkdf2.Key(sha256.New, password, salt, iter, len(want))
+	if err != nil {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare(key, want) == 1
+}
+
+// Token derives a token for a purpose from a password hash, so that the
+// tokens handed to the clients change with the password without being
+// stored.
+func Token(hash string, purpose string) string {
+	mac := hmac.New(sha256.New, []byte(hash))
+	mac.Write([]byte(purpose))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// CheckToken reports whether a token was derived from a password hash for a
+// purpose.
+func CheckToken(hash string, purpose string, token string) bool {
+	return hmac.Equal([]byte(Token(hash, purpose)), []byte(token))
+}
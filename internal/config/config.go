@@ -12,6 +12,7 @@ const CONFIG_FILE_NAME = ".gatorconfig.json"
 type Config struct {
 	DbUrl           string `json:"db_url"`
 	CurrentUserName string `json:"current_user_name"`
+	MigrationTable  string `json:"migration_table,omitempty"`
 }
 
 func Read() (*Config, error) {
@@ -43,6 +44,9 @@ func (c *Config) String() string {
 	s := fmt.Sprintf("Config\n")
 	s = s + fmt.Sprintf("  db_url: %s\n", c.DbUrl)
 	s = s + fmt.Sprintf("  current_user_name: %s\n", c.CurrentUserName)
+	if c.MigrationTable != "" {
+		s = s + fmt.Sprintf("  migration_table: %s\n", c.MigrationTable)
+	}
 
 	return s
 }
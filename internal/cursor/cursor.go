@@ -0,0 +1,46 @@
+// Package cursor encodes the opaque keyset pagination cursors of the post
+// listings, which point after a post by its (published_at, id) key, and
+// pages through the posts of a user with them.
+package cursor
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Encode builds the cursor pointing after the given post.
+func Encode(publishedAt time.Time, id uuid.UUID) string {
+	raw := publishedAt.Format(time.RFC3339Nano) + "|" + id.String()
+
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+func Decode(cursor string) (time.Time, uuid.UUID, error) {
+	invalid := fmt.Errorf("invalid page cursor '%s'", cursor)
+
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, uuid.UUID{}, invalid
+	}
+
+	publishedAtStr, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return time.Time{}, uuid.UUID{}, invalid
+	}
+
+	publishedAt, err := time.Parse(time.RFC3339Nano, publishedAtStr)
+	if err != nil {
+		return time.Time{}, uuid.UUID{}, invalid
+	}
+
+	id, err := uuid.Parse(idStr)
+	if err != nil {
+		return time.Time{}, uuid.UUID{}, invalid
+	}
+
+	return publishedAt, id, nil
+}
@@ -0,0 +1,123 @@
+package cursor
+
+import (
+	"context"
+	"database/sql"
+	"gator/internal/database"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PostsParams selects a page of the posts of the feeds followed by a user.
+// The cursor fields are set together, from the cursor of the previous page.
+type PostsParams struct {
+	ID                uuid.UUID
+	IncludeRead       bool
+	FeedID            uuid.NullUUID
+	Category          sql.NullString
+	Since             sql.NullTime
+	Until             sql.NullTime
+	CursorPublishedAt sql.NullTime
+	CursorID          uuid.NullUUID
+	OldestFirst       bool
+	Limit             int32
+	Offset            int32
+}
+
+// PostRow is a post of a page, with the read and starred states of the user.
+type PostRow = database.GetNewestPostsForUserRow
+
+// The first page starts after a key that sorts before, or after, every post
+var (
+	newestStart = time.Date(9999, time.December, 31, 23, 59, 59, 0, time.UTC)
+	oldestStart = time.Date(1, time.January, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// firstKey returns the key the first page of posts starts after.
+func firstKey(oldestFirst bool) (time.Time, uuid.UUID) {
+	if oldestFirst {
+		return oldestStart, uuid.Nil
+	}
+	return newestStart, uuid.Max
+}
+
+// Posts runs the newest or oldest first query of a page of posts.
+func Posts(ctx context.Context, q database.Querier, params PostsParams) ([]PostRow, error) {
+	arg := database.GetNewestPostsForUserParams{
+		ID:                params.ID,
+		IncludeRead:       params.IncludeRead,
+		FeedID:            params.FeedID,
+		Category:          params.Category,
+		Since:             params.Since,
+		Until:             params.Until,
+		CursorPublishedAt: params.CursorPublishedAt.Time,
+		CursorID:          params.CursorID.UUID,
+		Limit:             params.Limit,
+		Offset:            params.Offset,
+	}
+	if !params.CursorPublishedAt.Valid {
+		arg.CursorPublishedAt, arg.CursorID = firstKey(params.OldestFirst)
+	}
+
+	if !params.OldestFirst {
+		return q.GetNewestPostsForUser(ctx, arg)
+	}
+
+	rows, err := q.GetOldestPostsForUser(ctx, database.GetOldestPostsForUserParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	return postRows(rows), nil
+}
+
+// StarredPosts runs the newest or oldest first query of a page of the posts
+// starred by a user, whether they still follow their feed or not. The feed,
+// category and offset of the params do not apply.
+func StarredPosts(ctx context.Context, q database.Querier, params PostsParams) ([]PostRow, error) {
+	arg := database.GetNewestStarredPostsForUserParams{
+		ID:                params.ID,
+		IncludeRead:       params.IncludeRead,
+		Since:             params.Since,
+		Until:             params.Until,
+		CursorPublishedAt: params.CursorPublishedAt.Time,
+		CursorID:          params.CursorID.UUID,
+		Limit:             params.Limit,
+	}
+	if !params.CursorPublishedAt.Valid {
+		arg.CursorPublishedAt, arg.CursorID = firstKey(params.OldestFirst)
+	}
+
+	if !params.OldestFirst {
+		rows, err := q.GetNewestStarredPostsForUser(ctx, arg)
+		if err != nil {
+			return nil, err
+		}
+
+		return postRows(rows), nil
+	}
+
+	rows, err := q.GetOldestStarredPostsForUser(ctx, database.GetOldestStarredPostsForUserParams(arg))
+	if err != nil {
+		return nil, err
+	}
+
+	return postRows(rows), nil
+}
+
+// postRows converts the rows of the queries that select the same columns as
+// GetNewestPostsForUser.
+func postRows[Row ~struct {
+	Post    database.Post
+	User    database.User
+	Read    bool
+	Starred bool
+}](rows []Row) []PostRow {
+	posts := make([]PostRow, 0, len(rows))
+	for _, row := range rows {
+		posts = append(posts, PostRow(row))
+	}
+
+	return posts
+}
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 const createFeed = `-- name: CreateFeed :one
@@ -18,7 +19,7 @@ INSERT INTO
 VALUES
     ($1, $2, $3)
 RETURNING
-    id, name, url, user_id, last_fetched_at, created_at, updated_at
+    id, name, url, user_id, last_fetched_at, created_at, updated_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, skip_hours, skip_days, last_error, last_error_at, consecutive_failures, link, api_id
 `
 
 type CreateFeedParams struct {
@@ -38,6 +39,17 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 		&i.LastFetchedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.Link,
+		&i.ApiID,
 	)
 	return i, err
 }
@@ -45,15 +57,15 @@ func (q *Queries) CreateFeed(ctx context.Context, arg CreateFeedParams) (Feed, e
 const createFeedFollow = `-- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
     INSERT INTO
-        feed_follows (feed_id, user_id)
+        feed_follows (feed_id, user_id, category)
     VALUES
-        ($1, $2)
+        ($1, $2, $3)
     RETURNING
-        id, user_id, feed_id, created_at, updated_at
+        id, user_id, feed_id, created_at, updated_at, category
 )
 SELECT
-    inserted_feed_follow.id, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at,
-    feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at,
+    inserted_feed_follow.id, inserted_feed_follow.user_id, inserted_feed_follow.feed_id, inserted_feed_follow.created_at, inserted_feed_follow.updated_at, inserted_feed_follow.category,
+    feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at, feeds.etag, feeds.last_modified, feeds.next_fetch_at, feeds.fetch_interval_seconds, feeds.skip_hours, feeds.skip_days, feeds.last_error, feeds.last_error_at, feeds.consecutive_failures, feeds.link, feeds.api_id,
     users.id, users.name, users.created_at, users.updated_at
 FROM
     inserted_feed_follow
@@ -62,8 +74,9 @@ FROM
 `
 
 type CreateFeedFollowParams struct {
-	FeedID uuid.UUID
-	UserID uuid.UUID
+	FeedID   uuid.UUID
+	UserID   uuid.UUID
+	Category string
 }
 
 type CreateFeedFollowRow struct {
@@ -72,12 +85,13 @@ type CreateFeedFollowRow struct {
 	FeedID    uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Category  string
 	Feed      Feed
 	User      User
 }
 
 func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowParams) (CreateFeedFollowRow, error) {
-	row := q.db.QueryRowContext(ctx, createFeedFollow, arg.FeedID, arg.UserID)
+	row := q.db.QueryRowContext(ctx, createFeedFollow, arg.FeedID, arg.UserID, arg.Category)
 	var i CreateFeedFollowRow
 	err := row.Scan(
 		&i.ID,
@@ -85,6 +99,7 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.FeedID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Category,
 		&i.Feed.ID,
 		&i.Feed.Name,
 		&i.Feed.Url,
@@ -92,6 +107,17 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 		&i.Feed.LastFetchedAt,
 		&i.Feed.CreatedAt,
 		&i.Feed.UpdatedAt,
+		&i.Feed.Etag,
+		&i.Feed.LastModified,
+		&i.Feed.NextFetchAt,
+		&i.Feed.FetchIntervalSeconds,
+		pq.Array(&i.Feed.SkipHours),
+		pq.Array(&i.Feed.SkipDays),
+		&i.Feed.LastError,
+		&i.Feed.LastErrorAt,
+		&i.Feed.ConsecutiveFailures,
+		&i.Feed.Link,
+		&i.Feed.ApiID,
 		&i.User.ID,
 		&i.User.Name,
 		&i.User.CreatedAt,
@@ -100,6 +126,21 @@ func (q *Queries) CreateFeedFollow(ctx context.Context, arg CreateFeedFollowPara
 	return i, err
 }
 
+const deleteFeed = `-- name: DeleteFeed :execrows
+DELETE FROM
+    feeds
+WHERE
+    id = $1
+`
+
+func (q *Queries) DeleteFeed(ctx context.Context, id uuid.UUID) (int64, error) {
+	result, err := q.db.ExecContext(ctx, deleteFeed, id)
+	if err != nil {
+		return 0, err
+	}
+	return result.RowsAffected()
+}
+
 const deleteFeedFollow = `-- name: DeleteFeedFollow :exec
 DELETE FROM
     feed_follows
@@ -120,7 +161,7 @@ func (q *Queries) DeleteFeedFollow(ctx context.Context, arg DeleteFeedFollowPara
 
 const getAllFeedsWithUsers = `-- name: GetAllFeedsWithUsers :many
 SELECT
-    feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at,
+    feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at, feeds.etag, feeds.last_modified, feeds.next_fetch_at, feeds.fetch_interval_seconds, feeds.skip_hours, feeds.skip_days, feeds.last_error, feeds.last_error_at, feeds.consecutive_failures, feeds.link, feeds.api_id,
     users.id, users.name, users.created_at, users.updated_at
 FROM
     feeds
@@ -149,6 +190,17 @@ func (q *Queries) GetAllFeedsWithUsers(ctx context.Context) ([]GetAllFeedsWithUs
 			&i.Feed.LastFetchedAt,
 			&i.Feed.CreatedAt,
 			&i.Feed.UpdatedAt,
+			&i.Feed.Etag,
+			&i.Feed.LastModified,
+			&i.Feed.NextFetchAt,
+			&i.Feed.FetchIntervalSeconds,
+			pq.Array(&i.Feed.SkipHours),
+			pq.Array(&i.Feed.SkipDays),
+			&i.Feed.LastError,
+			&i.Feed.LastErrorAt,
+			&i.Feed.ConsecutiveFailures,
+			&i.Feed.Link,
+			&i.Feed.ApiID,
 			&i.User.ID,
 			&i.User.Name,
 			&i.User.CreatedAt,
@@ -167,9 +219,98 @@ func (q *Queries) GetAllFeedsWithUsers(ctx context.Context) ([]GetAllFeedsWithUs
 	return items, nil
 }
 
+const getFailingFeeds = `-- name: GetFailingFeeds :many
+SELECT
+    id, name, url, user_id, last_fetched_at, created_at, updated_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, skip_hours, skip_days, last_error, last_error_at, consecutive_failures, link, api_id
+FROM
+    feeds
+WHERE
+    consecutive_failures > 0
+ORDER BY
+    consecutive_failures DESC,
+    last_error_at DESC
+`
+
+func (q *Queries) GetFailingFeeds(ctx context.Context) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getFailingFeeds)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.Link,
+			&i.ApiID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getFeedByID = `-- name: GetFeedByID :one
+SELECT
+    feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at, feeds.etag, feeds.last_modified, feeds.next_fetch_at, feeds.fetch_interval_seconds, feeds.skip_hours, feeds.skip_days, feeds.last_error, feeds.last_error_at, feeds.consecutive_failures, feeds.link, feeds.api_id
+FROM
+    feeds
+WHERE
+    id = $1
+`
+
+func (q *Queries) GetFeedByID(ctx context.Context, id uuid.UUID) (Feed, error) {
+	row := q.db.QueryRowContext(ctx, getFeedByID, id)
+	var i Feed
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.Url,
+		&i.UserID,
+		&i.LastFetchedAt,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.Link,
+		&i.ApiID,
+	)
+	return i, err
+}
+
 const getFeedByUrl = `-- name: GetFeedByUrl :one
 SELECT
-    feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at
+    feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at, feeds.etag, feeds.last_modified, feeds.next_fetch_at, feeds.fetch_interval_seconds, feeds.skip_hours, feeds.skip_days, feeds.last_error, feeds.last_error_at, feeds.consecutive_failures, feeds.link, feeds.api_id
 FROM
     feeds
 WHERE
@@ -187,14 +328,26 @@ func (q *Queries) GetFeedByUrl(ctx context.Context, url string) (Feed, error) {
 		&i.LastFetchedAt,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.Etag,
+		&i.LastModified,
+		&i.NextFetchAt,
+		&i.FetchIntervalSeconds,
+		pq.Array(&i.SkipHours),
+		pq.Array(&i.SkipDays),
+		&i.LastError,
+		&i.LastErrorAt,
+		&i.ConsecutiveFailures,
+		&i.Link,
+		&i.ApiID,
 	)
 	return i, err
 }
 
 const getFeedFollowsByUser = `-- name: GetFeedFollowsByUser :many
 SELECT
-    feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at,
-    users.id, users.name, users.created_at, users.updated_at
+    feeds.id, feeds.name, feeds.url, feeds.user_id, feeds.last_fetched_at, feeds.created_at, feeds.updated_at, feeds.etag, feeds.last_modified, feeds.next_fetch_at, feeds.fetch_interval_seconds, feeds.skip_hours, feeds.skip_days, feeds.last_error, feeds.last_error_at, feeds.consecutive_failures, feeds.link, feeds.api_id,
+    users.id, users.name, users.created_at, users.updated_at,
+    feed_follows.category
 FROM
     feed_follows
     INNER JOIN feeds ON feed_follows.feed_id = feeds.id
@@ -204,8 +357,9 @@ WHERE
 `
 
 type GetFeedFollowsByUserRow struct {
-	Feed Feed
-	User User
+	Feed     Feed
+	User     User
+	Category string
 }
 
 func (q *Queries) GetFeedFollowsByUser(ctx context.Context, userID uuid.UUID) ([]GetFeedFollowsByUserRow, error) {
@@ -225,10 +379,22 @@ func (q *Queries) GetFeedFollowsByUser(ctx context.Context, userID uuid.UUID) ([
 			&i.Feed.LastFetchedAt,
 			&i.Feed.CreatedAt,
 			&i.Feed.UpdatedAt,
+			&i.Feed.Etag,
+			&i.Feed.LastModified,
+			&i.Feed.NextFetchAt,
+			&i.Feed.FetchIntervalSeconds,
+			pq.Array(&i.Feed.SkipHours),
+			pq.Array(&i.Feed.SkipDays),
+			&i.Feed.LastError,
+			&i.Feed.LastErrorAt,
+			&i.Feed.ConsecutiveFailures,
+			&i.Feed.Link,
+			&i.Feed.ApiID,
 			&i.User.ID,
 			&i.User.Name,
 			&i.User.CreatedAt,
 			&i.User.UpdatedAt,
+			&i.Category,
 		); err != nil {
 			return nil, err
 		}
@@ -243,30 +409,97 @@ func (q *Queries) GetFeedFollowsByUser(ctx context.Context, userID uuid.UUID) ([
 	return items, nil
 }
 
-const getNextFeedToFetch = `-- name: GetNextFeedToFetch :one
-SELECT
-    id, name, url, user_id, last_fetched_at, created_at, updated_at
-FROM
+const getNextFeedToFetch = `-- name: GetNextFeedToFetch :many
+UPDATE
     feeds
-ORDER BY
-    last_fetched_at ASC NULLS FIRST
-LIMIT
-    1
+SET
+    last_fetched_at = NOW(),
+    next_fetch_at = NOW() + make_interval(secs => fetch_interval_seconds),
+    updated_at = NOW()
+WHERE
+    id IN (
+        SELECT
+            id
+        FROM
+            feeds
+        WHERE
+            next_fetch_at <= NOW()
+        ORDER BY
+            next_fetch_at ASC
+        LIMIT
+            $1
+        FOR UPDATE
+            SKIP LOCKED
+    )
+RETURNING
+    id, name, url, user_id, last_fetched_at, created_at, updated_at, etag, last_modified, next_fetch_at, fetch_interval_seconds, skip_hours, skip_days, last_error, last_error_at, consecutive_failures, link, api_id
 `
 
-func (q *Queries) GetNextFeedToFetch(ctx context.Context) (Feed, error) {
-	row := q.db.QueryRowContext(ctx, getNextFeedToFetch)
-	var i Feed
-	err := row.Scan(
-		&i.ID,
-		&i.Name,
-		&i.Url,
-		&i.UserID,
-		&i.LastFetchedAt,
-		&i.CreatedAt,
-		&i.UpdatedAt,
-	)
-	return i, err
+func (q *Queries) GetNextFeedToFetch(ctx context.Context, limit int32) ([]Feed, error) {
+	rows, err := q.db.QueryContext(ctx, getNextFeedToFetch, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Feed
+	for rows.Next() {
+		var i Feed
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.Url,
+			&i.UserID,
+			&i.LastFetchedAt,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Etag,
+			&i.LastModified,
+			&i.NextFetchAt,
+			&i.FetchIntervalSeconds,
+			pq.Array(&i.SkipHours),
+			pq.Array(&i.SkipDays),
+			&i.LastError,
+			&i.LastErrorAt,
+			&i.ConsecutiveFailures,
+			&i.Link,
+			&i.ApiID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const markFeedAsFailed = `-- name: MarkFeedAsFailed :exec
+UPDATE
+    feeds
+SET
+    last_fetched_at = NOW(),
+    last_error = $1,
+    last_error_at = NOW(),
+    consecutive_failures = consecutive_failures + 1,
+    next_fetch_at = NOW() + $2::INTEGER * INTERVAL '1 second',
+    updated_at = NOW()
+WHERE
+    id = $3
+`
+
+type MarkFeedAsFailedParams struct {
+	LastError             string
+	NextFetchDelaySeconds int32
+	ID                    uuid.UUID
+}
+
+func (q *Queries) MarkFeedAsFailed(ctx context.Context, arg MarkFeedAsFailedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedAsFailed, arg.LastError, arg.NextFetchDelaySeconds, arg.ID)
+	return err
 }
 
 const markFeedAsFetched = `-- name: MarkFeedAsFetched :exec
@@ -274,12 +507,44 @@ UPDATE
     feeds
 SET
     last_fetched_at = NOW(),
+    etag = $1,
+    last_modified = $2,
+    next_fetch_at = NOW() + $3::INTEGER * INTERVAL '1 second',
+    fetch_interval_seconds = $4,
+    skip_hours = $5,
+    skip_days = $6,
+    link = CASE
+        WHEN $7::TEXT = '' THEN link
+        ELSE $7
+    END,
+    last_error = '',
+    consecutive_failures = 0,
     updated_at = NOW()
 WHERE
-    id = $1
+    id = $8
 `
 
-func (q *Queries) MarkFeedAsFetched(ctx context.Context, id uuid.UUID) error {
-	_, err := q.db.ExecContext(ctx, markFeedAsFetched, id)
+type MarkFeedAsFetchedParams struct {
+	Etag                  string
+	LastModified          string
+	NextFetchDelaySeconds int32
+	FetchIntervalSeconds  int32
+	SkipHours             []int32
+	SkipDays              []int32
+	Link                  string
+	ID                    uuid.UUID
+}
+
+func (q *Queries) MarkFeedAsFetched(ctx context.Context, arg MarkFeedAsFetchedParams) error {
+	_, err := q.db.ExecContext(ctx, markFeedAsFetched,
+		arg.Etag,
+		arg.LastModified,
+		arg.NextFetchDelaySeconds,
+		arg.FetchIntervalSeconds,
+		pq.Array(arg.SkipHours),
+		pq.Array(arg.SkipDays),
+		arg.Link,
+		arg.ID,
+	)
 	return err
 }
@@ -11,14 +11,32 @@ import (
 	"github.com/google/uuid"
 )
 
+type ApiCredential struct {
+	UserID       uuid.UUID
+	PasswordHash string
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
 type Feed struct {
-	ID            uuid.UUID
-	Name          string
-	Url           string
-	UserID        uuid.UUID
-	LastFetchedAt sql.NullTime
-	CreatedAt     time.Time
-	UpdatedAt     time.Time
+	ID                   uuid.UUID
+	Name                 string
+	Url                  string
+	UserID               uuid.UUID
+	LastFetchedAt        sql.NullTime
+	CreatedAt            time.Time
+	UpdatedAt            time.Time
+	Etag                 string
+	LastModified         string
+	NextFetchAt          time.Time
+	FetchIntervalSeconds int32
+	SkipHours            []int32
+	SkipDays             []int32
+	LastError            string
+	LastErrorAt          sql.NullTime
+	ConsecutiveFailures  int32
+	Link                 string
+	ApiID                int64
 }
 
 type FeedFollow struct {
@@ -27,17 +45,42 @@ type FeedFollow struct {
 	FeedID    uuid.UUID
 	CreatedAt time.Time
 	UpdatedAt time.Time
+	Category  string
+}
+
+type FeverCredential struct {
+	UserID    uuid.UUID
+	ApiKey    string
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
 
 type Post struct {
-	ID          uuid.UUID
-	Title       string
-	Url         string
-	Description string
-	PublishedAt time.Time
-	FeedID      uuid.UUID
-	CreatedAt   sql.NullTime
-	UpdatedAt   sql.NullTime
+	ID                   uuid.UUID
+	Title                string
+	Url                  string
+	Description          string
+	PublishedAt          time.Time
+	FeedID               uuid.NullUUID
+	CreatedAt            sql.NullTime
+	UpdatedAt            sql.NullTime
+	PublishedAtEstimated bool
+	Guid                 string
+	ContentHash          string
+	ApiID                int64
+	Author               string
+}
+
+type PostState struct {
+	ID        uuid.UUID
+	UserID    uuid.UUID
+	PostID    uuid.UUID
+	Read      bool
+	ReadAt    sql.NullTime
+	CreatedAt time.Time
+	UpdatedAt time.Time
+	Starred   bool
+	StarredAt sql.NullTime
 }
 
 type User struct {
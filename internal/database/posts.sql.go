@@ -7,36 +7,315 @@ package database
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
-const createPost = `-- name: CreatePost :one
-INSERT INTO
-    posts (title, url, description, published_at, feed_id)
-VALUES
-    ($1, $2, $3, $4, $5)
-RETURNING
-    id, title, url, description, published_at, feed_id, created_at, updated_at
+const adoptLegacyPost = `-- name: AdoptLegacyPost :exec
+UPDATE
+    posts
+SET
+    guid = $1
+WHERE
+    feed_id = $2
+    AND guid = $3
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            posts AS existing
+        WHERE
+            existing.feed_id = $2
+            AND existing.guid = $1
+    )
 `
 
-type CreatePostParams struct {
-	Title       string
-	Url         string
-	Description string
-	PublishedAt time.Time
-	FeedID      uuid.UUID
+type AdoptLegacyPostParams struct {
+	Guid   string
+	FeedID uuid.NullUUID
+	Url    string
 }
 
-func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, error) {
-	row := q.db.QueryRowContext(ctx, createPost,
-		arg.Title,
-		arg.Url,
-		arg.Description,
-		arg.PublishedAt,
+func (q *Queries) AdoptLegacyPost(ctx context.Context, arg AdoptLegacyPostParams) error {
+	_, err := q.db.ExecContext(ctx, adoptLegacyPost, arg.Guid, arg.FeedID, arg.Url)
+	return err
+}
+
+const countPostsForUser = `-- name: CountPostsForUser :one
+SELECT
+    COUNT(*)
+FROM
+    posts
+    INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE
+    feed_follows.user_id = $1
+`
+
+func (q *Queries) CountPostsForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	row := q.db.QueryRowContext(ctx, countPostsForUser, userID)
+	var count int64
+	err := row.Scan(&count)
+	return count, err
+}
+
+const getNewestPostsForUser = `-- name: GetNewestPostsForUser :many
+SELECT
+    posts.id, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.created_at, posts.updated_at, posts.published_at_estimated, posts.guid, posts.content_hash, posts.api_id, posts.author,
+    users.id, users.name, users.created_at, users.updated_at,
+    COALESCE(post_states.read, FALSE)::BOOLEAN AS read,
+    COALESCE(post_states.starred, FALSE)::BOOLEAN AS starred
+FROM
+    posts
+    INNER JOIN feeds ON posts.feed_id = feeds.id
+    INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id
+    INNER JOIN users ON feed_follows.user_id = users.id
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND users.id = post_states.user_id
+WHERE
+    users.id = $1
+    AND (
+        $2::BOOLEAN
+        OR NOT COALESCE(post_states.read, FALSE)
+    )
+    AND (
+        $3::UUID IS NULL
+        OR posts.feed_id = $3
+    )
+    AND (
+        $4::TEXT IS NULL
+        OR feed_follows.category = $4
+    )
+    AND (
+        $5::TIMESTAMP IS NULL
+        OR posts.published_at >= $5
+    )
+    AND (
+        $6::TIMESTAMP IS NULL
+        OR posts.published_at < $6
+    )
+    AND (posts.published_at, posts.id) < (
+        $7::TIMESTAMP,
+        $8::UUID
+    )
+ORDER BY
+    posts.published_at DESC,
+    posts.id DESC
+LIMIT
+    $9
+OFFSET
+    $10
+`
+
+type GetNewestPostsForUserParams struct {
+	ID                uuid.UUID
+	IncludeRead       bool
+	FeedID            uuid.NullUUID
+	Category          sql.NullString
+	Since             sql.NullTime
+	Until             sql.NullTime
+	CursorPublishedAt time.Time
+	CursorID          uuid.UUID
+	Limit             int32
+	Offset            int32
+}
+
+type GetNewestPostsForUserRow struct {
+	Post    Post
+	User    User
+	Read    bool
+	Starred bool
+}
+
+func (q *Queries) GetNewestPostsForUser(ctx context.Context, arg GetNewestPostsForUserParams) ([]GetNewestPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getNewestPostsForUser,
+		arg.ID,
+		arg.IncludeRead,
+		arg.FeedID,
+		arg.Category,
+		arg.Since,
+		arg.Until,
+		arg.CursorPublishedAt,
+		arg.CursorID,
+		arg.Limit,
+		arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetNewestPostsForUserRow
+	for rows.Next() {
+		var i GetNewestPostsForUserRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.Title,
+			&i.Post.Url,
+			&i.Post.Description,
+			&i.Post.PublishedAt,
+			&i.Post.FeedID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.PublishedAtEstimated,
+			&i.Post.Guid,
+			&i.Post.ContentHash,
+			&i.Post.ApiID,
+			&i.Post.Author,
+			&i.User.ID,
+			&i.User.Name,
+			&i.User.CreatedAt,
+			&i.User.UpdatedAt,
+			&i.Read,
+			&i.Starred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getOldestPostsForUser = `-- name: GetOldestPostsForUser :many
+SELECT
+    posts.id, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.created_at, posts.updated_at, posts.published_at_estimated, posts.guid, posts.content_hash, posts.api_id, posts.author,
+    users.id, users.name, users.created_at, users.updated_at,
+    COALESCE(post_states.read, FALSE)::BOOLEAN AS read,
+    COALESCE(post_states.starred, FALSE)::BOOLEAN AS starred
+FROM
+    posts
+    INNER JOIN feeds ON posts.feed_id = feeds.id
+    INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id
+    INNER JOIN users ON feed_follows.user_id = users.id
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND users.id = post_states.user_id
+WHERE
+    users.id = $1
+    AND (
+        $2::BOOLEAN
+        OR NOT COALESCE(post_states.read, FALSE)
+    )
+    AND (
+        $3::UUID IS NULL
+        OR posts.feed_id = $3
+    )
+    AND (
+        $4::TEXT IS NULL
+        OR feed_follows.category = $4
+    )
+    AND (
+        $5::TIMESTAMP IS NULL
+        OR posts.published_at >= $5
+    )
+    AND (
+        $6::TIMESTAMP IS NULL
+        OR posts.published_at < $6
+    )
+    AND (posts.published_at, posts.id) > (
+        $7::TIMESTAMP,
+        $8::UUID
+    )
+ORDER BY
+    posts.published_at ASC,
+    posts.id ASC
+LIMIT
+    $9
+OFFSET
+    $10
+`
+
+type GetOldestPostsForUserParams struct {
+	ID                uuid.UUID
+	IncludeRead       bool
+	FeedID            uuid.NullUUID
+	Category          sql.NullString
+	Since             sql.NullTime
+	Until             sql.NullTime
+	CursorPublishedAt time.Time
+	CursorID          uuid.UUID
+	Limit             int32
+	Offset            int32
+}
+
+type GetOldestPostsForUserRow struct {
+	Post    Post
+	User    User
+	Read    bool
+	Starred bool
+}
+
+func (q *Queries) GetOldestPostsForUser(ctx context.Context, arg GetOldestPostsForUserParams) ([]GetOldestPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getOldestPostsForUser,
+		arg.ID,
+		arg.IncludeRead,
 		arg.FeedID,
+		arg.Category,
+		arg.Since,
+		arg.Until,
+		arg.CursorPublishedAt,
+		arg.CursorID,
+		arg.Limit,
+		arg.Offset,
 	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetOldestPostsForUserRow
+	for rows.Next() {
+		var i GetOldestPostsForUserRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.Title,
+			&i.Post.Url,
+			&i.Post.Description,
+			&i.Post.PublishedAt,
+			&i.Post.FeedID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.PublishedAtEstimated,
+			&i.Post.Guid,
+			&i.Post.ContentHash,
+			&i.Post.ApiID,
+			&i.Post.Author,
+			&i.User.ID,
+			&i.User.Name,
+			&i.User.CreatedAt,
+			&i.User.UpdatedAt,
+			&i.Read,
+			&i.Starred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostByID = `-- name: GetPostByID :one
+SELECT
+    id, title, url, description, published_at, feed_id, created_at, updated_at, published_at_estimated, guid, content_hash, api_id, author
+FROM
+    posts
+WHERE
+    id = $1
+`
+
+func (q *Queries) GetPostByID(ctx context.Context, id uuid.UUID) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByID, id)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -47,21 +326,37 @@ func (q *Queries) CreatePost(ctx context.Context, arg CreatePostParams) (Post, e
 		&i.FeedID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PublishedAtEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ApiID,
+		&i.Author,
 	)
 	return i, err
 }
 
 const getPostByUrl = `-- name: GetPostByUrl :one
 SELECT
-    id, title, url, description, published_at, feed_id, created_at, updated_at
+    posts.id, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.created_at, posts.updated_at, posts.published_at_estimated, posts.guid, posts.content_hash, posts.api_id, posts.author
 FROM
     posts
+    INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
 WHERE
-    url = $1
+    feed_follows.user_id = $1
+    AND posts.url = $2
+ORDER BY
+    posts.published_at DESC
+LIMIT
+    1
 `
 
-func (q *Queries) GetPostByUrl(ctx context.Context, url string) (Post, error) {
-	row := q.db.QueryRowContext(ctx, getPostByUrl, url)
+type GetPostByUrlParams struct {
+	UserID uuid.UUID
+	Url    string
+}
+
+func (q *Queries) GetPostByUrl(ctx context.Context, arg GetPostByUrlParams) (Post, error) {
+	row := q.db.QueryRowContext(ctx, getPostByUrl, arg.UserID, arg.Url)
 	var i Post
 	err := row.Scan(
 		&i.ID,
@@ -72,46 +367,332 @@ func (q *Queries) GetPostByUrl(ctx context.Context, url string) (Post, error) {
 		&i.FeedID,
 		&i.CreatedAt,
 		&i.UpdatedAt,
+		&i.PublishedAtEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ApiID,
+		&i.Author,
 	)
 	return i, err
 }
 
-const getPostsForUser = `-- name: GetPostsForUser :many
+const getPostsForUserByApiIDRange = `-- name: GetPostsForUserByApiIDRange :many
+SELECT
+    posts.id, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.created_at, posts.updated_at, posts.published_at_estimated, posts.guid, posts.content_hash, posts.api_id, posts.author,
+    COALESCE(post_states.read, FALSE)::BOOLEAN AS read,
+    COALESCE(post_states.starred, FALSE)::BOOLEAN AS starred
+FROM
+    posts
+    INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND feed_follows.user_id = post_states.user_id
+WHERE
+    feed_follows.user_id = $1
+    AND (
+        $2::BIGINT IS NULL
+        OR posts.api_id > $2
+    )
+    AND (
+        $3::BIGINT IS NULL
+        OR posts.api_id < $3
+    )
+ORDER BY
+    CASE
+        WHEN $3::BIGINT IS NULL THEN posts.api_id
+    END ASC,
+    posts.api_id DESC
+LIMIT
+    $4
+`
+
+type GetPostsForUserByApiIDRangeParams struct {
+	UserID  uuid.UUID
+	SinceID sql.NullInt64
+	MaxID   sql.NullInt64
+	Limit   int32
+}
+
+type GetPostsForUserByApiIDRangeRow struct {
+	Post    Post
+	Read    bool
+	Starred bool
+}
+
+func (q *Queries) GetPostsForUserByApiIDRange(ctx context.Context, arg GetPostsForUserByApiIDRangeParams) ([]GetPostsForUserByApiIDRangeRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserByApiIDRange,
+		arg.UserID,
+		arg.SinceID,
+		arg.MaxID,
+		arg.Limit,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserByApiIDRangeRow
+	for rows.Next() {
+		var i GetPostsForUserByApiIDRangeRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.Title,
+			&i.Post.Url,
+			&i.Post.Description,
+			&i.Post.PublishedAt,
+			&i.Post.FeedID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.PublishedAtEstimated,
+			&i.Post.Guid,
+			&i.Post.ContentHash,
+			&i.Post.ApiID,
+			&i.Post.Author,
+			&i.Read,
+			&i.Starred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getPostsForUserByApiIDs = `-- name: GetPostsForUserByApiIDs :many
+SELECT
+    posts.id, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.created_at, posts.updated_at, posts.published_at_estimated, posts.guid, posts.content_hash, posts.api_id, posts.author,
+    COALESCE(post_states.read, FALSE)::BOOLEAN AS read,
+    COALESCE(post_states.starred, FALSE)::BOOLEAN AS starred
+FROM
+    posts
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND post_states.user_id = $1
+WHERE
+    posts.api_id = ANY($2::BIGINT[])
+    AND (
+        post_states.starred
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                feed_follows
+            WHERE
+                feed_follows.feed_id = posts.feed_id
+                AND feed_follows.user_id = $1
+        )
+    )
+ORDER BY
+    posts.published_at DESC,
+    posts.id DESC
+`
+
+type GetPostsForUserByApiIDsParams struct {
+	UserID uuid.UUID
+	ApiIds []int64
+}
+
+type GetPostsForUserByApiIDsRow struct {
+	Post    Post
+	Read    bool
+	Starred bool
+}
+
+func (q *Queries) GetPostsForUserByApiIDs(ctx context.Context, arg GetPostsForUserByApiIDsParams) ([]GetPostsForUserByApiIDsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getPostsForUserByApiIDs, arg.UserID, pq.Array(arg.ApiIds))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetPostsForUserByApiIDsRow
+	for rows.Next() {
+		var i GetPostsForUserByApiIDsRow
+		if err := rows.Scan(
+			&i.Post.ID,
+			&i.Post.Title,
+			&i.Post.Url,
+			&i.Post.Description,
+			&i.Post.PublishedAt,
+			&i.Post.FeedID,
+			&i.Post.CreatedAt,
+			&i.Post.UpdatedAt,
+			&i.Post.PublishedAtEstimated,
+			&i.Post.Guid,
+			&i.Post.ContentHash,
+			&i.Post.ApiID,
+			&i.Post.Author,
+			&i.Read,
+			&i.Starred,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnreadCountsForUser = `-- name: GetUnreadCountsForUser :many
+SELECT
+    posts.feed_id,
+    COUNT(*) AS count,
+    MAX(posts.published_at)::TIMESTAMP AS newest_published_at
+FROM
+    posts
+    INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND feed_follows.user_id = post_states.user_id
+WHERE
+    feed_follows.user_id = $1
+    AND NOT COALESCE(post_states.read, FALSE)
+GROUP BY
+    posts.feed_id
+`
+
+type GetUnreadCountsForUserRow struct {
+	FeedID            uuid.NullUUID
+	Count             int64
+	NewestPublishedAt time.Time
+}
+
+func (q *Queries) GetUnreadCountsForUser(ctx context.Context, userID uuid.UUID) ([]GetUnreadCountsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, getUnreadCountsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetUnreadCountsForUserRow
+	for rows.Next() {
+		var i GetUnreadCountsForUserRow
+		if err := rows.Scan(&i.FeedID, &i.Count, &i.NewestPublishedAt); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getUnreadPostApiIDsForUser = `-- name: GetUnreadPostApiIDsForUser :many
 SELECT
-    posts.id, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.created_at, posts.updated_at,
-    users.id, users.name, users.created_at, users.updated_at
+    posts.api_id
+FROM
+    posts
+    INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND feed_follows.user_id = post_states.user_id
+WHERE
+    feed_follows.user_id = $1
+    AND NOT COALESCE(post_states.read, FALSE)
+ORDER BY
+    posts.api_id
+`
+
+func (q *Queries) GetUnreadPostApiIDsForUser(ctx context.Context, userID uuid.UUID) ([]int64, error) {
+	rows, err := q.db.QueryContext(ctx, getUnreadPostApiIDsForUser, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []int64
+	for rows.Next() {
+		var api_id int64
+		if err := rows.Scan(&api_id); err != nil {
+			return nil, err
+		}
+		items = append(items, api_id)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const searchPostsForUser = `-- name: SearchPostsForUser :many
+SELECT
+    posts.id, posts.title, posts.url, posts.description, posts.published_at, posts.feed_id, posts.created_at, posts.updated_at, posts.published_at_estimated, posts.guid, posts.content_hash, posts.api_id, posts.author,
+    feeds.name AS feed_name,
+    ts_rank(
+        setweight(to_tsvector('english', posts.title), 'A') || setweight(to_tsvector('english', posts.description), 'B'),
+        search_query
+    )::REAL AS rank,
+    ts_headline(
+        'english',
+        posts.title || ' ' || posts.description,
+        search_query,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=10'
+    )::TEXT AS snippet
 FROM
     posts
     INNER JOIN feeds ON posts.feed_id = feeds.id
-    INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id
-    INNER JOIN users ON feed_follows.user_id = users.id
+    INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id,
+    websearch_to_tsquery('english', $1) AS search_query
 WHERE
-    users.id = $1
+    feed_follows.user_id = $2
+    AND (
+        setweight(to_tsvector('english', posts.title), 'A') || setweight(to_tsvector('english', posts.description), 'B')
+    ) @@ search_query
+    AND (
+        $3::UUID IS NULL
+        OR posts.feed_id = $3
+    )
+    AND (
+        $4::TIMESTAMP IS NULL
+        OR posts.published_at >= $4
+    )
 ORDER BY
+    rank DESC,
     posts.published_at DESC
 LIMIT
-    $2
+    $5
 `
 
-type GetPostsForUserParams struct {
-	ID    uuid.UUID
-	Limit int32
+type SearchPostsForUserParams struct {
+	Query  string
+	UserID uuid.UUID
+	FeedID uuid.NullUUID
+	Since  sql.NullTime
+	Limit  int32
 }
 
-type GetPostsForUserRow struct {
-	Post Post
-	User User
+type SearchPostsForUserRow struct {
+	Post     Post
+	FeedName string
+	Rank     float32
+	Snippet  string
 }
 
-func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams) ([]GetPostsForUserRow, error) {
-	rows, err := q.db.QueryContext(ctx, getPostsForUser, arg.ID, arg.Limit)
+func (q *Queries) SearchPostsForUser(ctx context.Context, arg SearchPostsForUserParams) ([]SearchPostsForUserRow, error) {
+	rows, err := q.db.QueryContext(ctx, searchPostsForUser,
+		arg.Query,
+		arg.UserID,
+		arg.FeedID,
+		arg.Since,
+		arg.Limit,
+	)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
-	var items []GetPostsForUserRow
+	var items []SearchPostsForUserRow
 	for rows.Next() {
-		var i GetPostsForUserRow
+		var i SearchPostsForUserRow
 		if err := rows.Scan(
 			&i.Post.ID,
 			&i.Post.Title,
@@ -121,10 +702,14 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 			&i.Post.FeedID,
 			&i.Post.CreatedAt,
 			&i.Post.UpdatedAt,
-			&i.User.ID,
-			&i.User.Name,
-			&i.User.CreatedAt,
-			&i.User.UpdatedAt,
+			&i.Post.PublishedAtEstimated,
+			&i.Post.Guid,
+			&i.Post.ContentHash,
+			&i.Post.ApiID,
+			&i.Post.Author,
+			&i.FeedName,
+			&i.Rank,
+			&i.Snippet,
 		); err != nil {
 			return nil, err
 		}
@@ -138,3 +723,100 @@ func (q *Queries) GetPostsForUser(ctx context.Context, arg GetPostsForUserParams
 	}
 	return items, nil
 }
+
+const upsertPost = `-- name: UpsertPost :one
+INSERT INTO
+    posts (
+        title,
+        url,
+        description,
+        published_at,
+        published_at_estimated,
+        feed_id,
+        guid,
+        content_hash,
+        author
+    )
+VALUES
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET
+    title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    published_at = CASE
+        WHEN EXCLUDED.published_at_estimated THEN posts.published_at
+        ELSE EXCLUDED.published_at
+    END,
+    published_at_estimated = posts.published_at_estimated
+    AND EXCLUDED.published_at_estimated,
+    content_hash = EXCLUDED.content_hash,
+    author = EXCLUDED.author,
+    updated_at = NOW()
+WHERE
+    posts.content_hash <> EXCLUDED.content_hash
+RETURNING
+    id, title, url, description, published_at, feed_id, created_at, updated_at, published_at_estimated, guid, content_hash, api_id, author,
+    (xmax = 0)::BOOLEAN AS inserted
+`
+
+type UpsertPostParams struct {
+	Title                string
+	Url                  string
+	Description          string
+	PublishedAt          time.Time
+	PublishedAtEstimated bool
+	FeedID               uuid.NullUUID
+	Guid                 string
+	ContentHash          string
+	Author               string
+}
+
+type UpsertPostRow struct {
+	ID                   uuid.UUID
+	Title                string
+	Url                  string
+	Description          string
+	PublishedAt          time.Time
+	FeedID               uuid.NullUUID
+	CreatedAt            sql.NullTime
+	UpdatedAt            sql.NullTime
+	PublishedAtEstimated bool
+	Guid                 string
+	ContentHash          string
+	ApiID                int64
+	Author               string
+	Inserted             bool
+}
+
+func (q *Queries) UpsertPost(ctx context.Context, arg UpsertPostParams) (UpsertPostRow, error) {
+	row := q.db.QueryRowContext(ctx, upsertPost,
+		arg.Title,
+		arg.Url,
+		arg.Description,
+		arg.PublishedAt,
+		arg.PublishedAtEstimated,
+		arg.FeedID,
+		arg.Guid,
+		arg.ContentHash,
+		arg.Author,
+	)
+	var i UpsertPostRow
+	err := row.Scan(
+		&i.ID,
+		&i.Title,
+		&i.Url,
+		&i.Description,
+		&i.PublishedAt,
+		&i.FeedID,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.PublishedAtEstimated,
+		&i.Guid,
+		&i.ContentHash,
+		&i.ApiID,
+		&i.Author,
+		&i.Inserted,
+	)
+	return i, err
+}
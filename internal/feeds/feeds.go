@@ -0,0 +1,53 @@
+// Package feeds holds the feed operations shared by the CLI and the servers.
+package feeds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"gator/internal/database"
+	"net/url"
+	"strings"
+)
+
+// ErrInvalidURL is returned for feed URLs that are not http or https ones.
+var ErrInvalidURL = errors.New("must be an http or https URL")
+
+// ParseURL parses a feed URL, which must be an http or https one.
+func ParseURL(rawURL string) (*url.URL, error) {
+	feedURL, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || (feedURL.Scheme != "http" && feedURL.Scheme != "https") || feedURL.Host == "" {
+		return nil, fmt.Errorf("invalid feed URL '%s': %w", rawURL, ErrInvalidURL)
+	}
+
+	return feedURL, nil
+}
+
+// Add creates a feed and makes user follow it. The URL is stored normalized.
+// q should be bound to a transaction, so that the feed is not left behind
+// when following it fails.
+func Add(ctx context.Context, q database.Querier, user database.User, name string, rawURL string) (database.Feed, error) {
+	feedURL, err := ParseURL(rawURL)
+	if err != nil {
+		return database.Feed{}, err
+	}
+
+	feed, err := q.CreateFeed(ctx, database.CreateFeedParams{
+		Name:   name,
+		Url:    feedURL.String(),
+		UserID: user.ID,
+	})
+	if err != nil {
+		return feed, fmt.Errorf("failed to create feed: %w", err)
+	}
+
+	_, err = q.CreateFeedFollow(ctx, database.CreateFeedFollowParams{
+		FeedID: feed.ID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		return feed, fmt.Errorf("failed to follow feed: %w", err)
+	}
+
+	return feed, nil
+}
@@ -0,0 +1,34 @@
+package feeds
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestParseURL(t *testing.T) {
+	tests := []struct {
+		rawURL string
+		want   string
+	}{
+		{"https://example.com/feed", "https://example.com/feed"},
+		{" http://example.com/feed.xml\n", "http://example.com/feed.xml"},
+		{"ftp://example.com/feed", ""},
+		{"example.com/feed", ""},
+		{"https:///feed", ""},
+		{"://", ""},
+	}
+
+	for _, tt := range tests {
+		feedURL, err := ParseURL(tt.rawURL)
+		if tt.want == "" {
+			if !errors.Is(err, ErrInvalidURL) {
+				t.Errorf("ParseURL(%q) error = %v, want ErrInvalidURL", tt.rawURL, err)
+			}
+			continue
+		}
+
+		if err != nil || feedURL.String() != tt.want {
+			t.Errorf("ParseURL(%q) = %v, %v, want %s", tt.rawURL, feedURL, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,423 @@
+// Package fever serves the Fever API, which lightweight clients sync with.
+package fever
+
+import (
+	"crypto/md5"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"gator/internal/api"
+	"gator/internal/database"
+	"hash/crc32"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	apiVersion = 3
+	// itemsPerPage is the number of items returned at once, fixed by the
+	// Fever API.
+	itemsPerPage = 50
+)
+
+// APIKey returns the key a user authenticates with, the MD5 of
+// 'username:password' as the clients compute it.
+func APIKey(username string, password string) string {
+	sum := md5.Sum([]byte(username + ":" + password))
+	return hex.EncodeToString(sum[:])
+}
+
+// groupID returns the ID of the group of a category. Categories have no ID
+// of their own, so it is derived from the name to stay stable across syncs.
+func groupID(category string) int64 {
+	return int64(crc32.ChecksumIEEE([]byte(category))&0x7fffffff) + 1
+}
+
+type Server struct {
+	store api.Store
+}
+
+func NewServer(store api.Store) *Server {
+	return &Server{store: store}
+}
+
+type group struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+}
+
+type feedsGroup struct {
+	GroupID int64  `json:"group_id"`
+	FeedIDs string `json:"feed_ids"`
+}
+
+type feed struct {
+	ID                int64  `json:"id"`
+	FaviconID         int64  `json:"favicon_id"`
+	Title             string `json:"title"`
+	URL               string `json:"url"`
+	SiteURL           string `json:"site_url"`
+	IsSpark           int    `json:"is_spark"`
+	LastUpdatedOnTime int64  `json:"last_updated_on_time"`
+}
+
+type item struct {
+	ID            int64  `json:"id"`
+	FeedID        int64  `json:"feed_id"`
+	Title         string `json:"title"`
+	Author        string `json:"author"`
+	HTML          string `json:"html"`
+	URL           string `json:"url"`
+	IsSaved       int    `json:"is_saved"`
+	IsRead        int    `json:"is_read"`
+	CreatedOnTime int64  `json:"created_on_time"`
+}
+
+// ServeHTTP answers the requests made to the endpoint with the api query
+// parameter. The api_key form value authenticates the user, and the other
+// parameters select the data returned, after the mark one is applied.
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !r.URL.Query().Has("api") {
+		http.NotFound(w, r)
+		return
+	}
+
+	if err := r.ParseForm(); err != nil {
+		api.WriteError(w, r, api.BadRequest("invalid form: %v", err))
+		return
+	}
+
+	response := map[string]any{"api_version": apiVersion, "auth": 0}
+
+	user, err := srv.store.GetUserByFeverApiKey(r.Context(), strings.ToLower(r.Form.Get("api_key")))
+	if errors.Is(err, sql.ErrNoRows) {
+		api.WriteJSON(w, http.StatusOK, response)
+		return
+	}
+	if err != nil {
+		api.WriteError(w, r, fmt.Errorf("failed to get user by API key: %w", err))
+		return
+	}
+
+	response["auth"] = 1
+	response["last_refreshed_on_time"] = time.Now().Unix()
+
+	follows, err := srv.store.GetFeedFollowsByUser(r.Context(), user.ID)
+	if err != nil {
+		api.WriteError(w, r, fmt.Errorf("failed to get feeds for user '%s': %w", user.Name, err))
+		return
+	}
+
+	if r.Form.Has("mark") {
+		if err := srv.mark(r, user, follows); err != nil {
+			api.WriteError(w, r, err)
+			return
+		}
+	}
+
+	if r.Form.Has("groups") {
+		response["groups"] = groups(follows)
+		response["feeds_groups"] = feedsGroups(follows)
+	}
+
+	if r.Form.Has("feeds") {
+		response["feeds"] = feeds(follows)
+		response["feeds_groups"] = feedsGroups(follows)
+	}
+
+	if r.Form.Has("favicons") {
+		response["favicons"] = []any{}
+	}
+
+	if r.Form.Has("items") {
+		items, total, err := srv.items(r, user, follows)
+		if err != nil {
+			api.WriteError(w, r, err)
+			return
+		}
+		response["items"] = items
+		response["total_items"] = total
+	}
+
+	if r.Form.Has("unread_item_ids") {
+		ids, err := srv.store.GetUnreadPostApiIDsForUser(r.Context(), user.ID)
+		if err != nil {
+			api.WriteError(w, r, fmt.Errorf("failed to get unread posts for user '%s': %w", user.Name, err))
+			return
+		}
+		response["unread_item_ids"] = joinIDs(ids)
+	}
+
+	if r.Form.Has("saved_item_ids") {
+		rows, err := srv.store.GetStarredPostsForUser(r.Context(), user.ID)
+		if err != nil {
+			api.WriteError(w, r, fmt.Errorf("failed to get starred posts for user '%s': %w", user.Name, err))
+			return
+		}
+
+		ids := []int64{}
+		for _, row := range rows {
+			ids = append(ids, row.Post.ApiID)
+		}
+		response["saved_item_ids"] = joinIDs(ids)
+	}
+
+	api.WriteJSON(w, http.StatusOK, response)
+}
+
+// groups returns a group for each category of the followed feeds.
+func groups(follows []database.GetFeedFollowsByUserRow) []group {
+	groups := []group{}
+	seen := map[string]bool{}
+	for _, follow := range follows {
+		if follow.Category != "" && !seen[follow.Category] {
+			seen[follow.Category] = true
+			groups = append(groups, group{ID: groupID(follow.Category), Title: follow.Category})
+		}
+	}
+
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Title < groups[j].Title })
+	return groups
+}
+
+func feedsGroups(follows []database.GetFeedFollowsByUserRow) []feedsGroup {
+	feedIDs := map[string][]int64{}
+	for _, follow := range follows {
+		if follow.Category != "" {
+			feedIDs[follow.Category] = append(feedIDs[follow.Category], follow.Feed.ApiID)
+		}
+	}
+
+	feedsGroups := []feedsGroup{}
+	for _, g := range groups(follows) {
+		feedsGroups = append(feedsGroups, feedsGroup{GroupID: g.ID, FeedIDs: joinIDs(feedIDs[g.Title])})
+	}
+	return feedsGroups
+}
+
+func feeds(follows []database.GetFeedFollowsByUserRow) []feed {
+	feeds := []feed{}
+	for _, follow := range follows {
+		f := feed{
+			ID:      follow.Feed.ApiID,
+			Title:   follow.Feed.Name,
+			URL:     follow.Feed.Url,
+			SiteURL: follow.Feed.Link,
+		}
+		if follow.Feed.LastFetchedAt.Valid {
+			f.LastUpdatedOnTime = follow.Feed.LastFetchedAt.Time.Unix()
+		}
+		feeds = append(feeds, f)
+	}
+	return feeds
+}
+
+// items returns a page of posts, selected by the with_ids, since_id or
+// max_id parameters, and the number of posts of the followed feeds.
+func (srv *Server) items(r *http.Request, user database.User, follows []database.GetFeedFollowsByUserRow) ([]item, int64, error) {
+	feedIDs := map[uuid.UUID]int64{}
+	for _, follow := range follows {
+		feedIDs[follow.Feed.ID] = follow.Feed.ApiID
+	}
+
+	newItem := func(post database.Post, read bool, starred bool) item {
+		it := item{
+			ID:            post.ApiID,
+			Title:         post.Title,
+			Author:        post.Author,
+			HTML:          post.Description,
+			URL:           post.Url,
+			CreatedOnTime: post.PublishedAt.Unix(),
+		}
+		if post.FeedID.Valid {
+			it.FeedID = feedIDs[post.FeedID.UUID]
+		}
+		if read {
+			it.IsRead = 1
+		}
+		if starred {
+			it.IsSaved = 1
+		}
+		return it
+	}
+
+	items := []item{}
+
+	if value := r.Form.Get("with_ids"); value != "" {
+		ids, err := parseIDs(value)
+		if err != nil {
+			return nil, 0, err
+		}
+		if len(ids) > itemsPerPage {
+			return nil, 0, api.BadRequest("too many with_ids: at most %d items can be requested at once", itemsPerPage)
+		}
+
+		rows, err := srv.store.GetPostsForUserByApiIDs(r.Context(), database.GetPostsForUserByApiIDsParams{
+			UserID: user.ID,
+			ApiIds: ids,
+		})
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get posts for user '%s': %w", user.Name, err)
+		}
+		for _, row := range rows {
+			items = append(items, newItem(row.Post, row.Read, row.Starred))
+		}
+	} else {
+		params := database.GetPostsForUserByApiIDRangeParams{
+			UserID: user.ID,
+			Limit:  itemsPerPage,
+		}
+		for name, target := range map[string]*sql.NullInt64{"since_id": &params.SinceID, "max_id": &params.MaxID} {
+			if value := r.Form.Get(name); value != "" {
+				id, err := strconv.ParseInt(value, 10, 64)
+				if err != nil {
+					return nil, 0, api.BadRequest("invalid %s '%s': must be an integer", name, value)
+				}
+				*target = sql.NullInt64{Int64: id, Valid: true}
+			}
+		}
+
+		rows, err := srv.store.GetPostsForUserByApiIDRange(r.Context(), params)
+		if err != nil {
+			return nil, 0, fmt.Errorf("failed to get posts for user '%s': %w", user.Name, err)
+		}
+		for _, row := range rows {
+			items = append(items, newItem(row.Post, row.Read, row.Starred))
+		}
+	}
+
+	total, err := srv.store.CountPostsForUser(r.Context(), user.ID)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to count posts for user '%s': %w", user.Name, err)
+	}
+
+	return items, total, nil
+}
+
+// mark applies the mark, as, id and before parameters: an item is marked as
+// read, unread, saved or unsaved, and a feed or a group as read up to
+// before. The group 0 holds all the feeds.
+func (srv *Server) mark(r *http.Request, user database.User, follows []database.GetFeedFollowsByUserRow) error {
+	kind, as := r.Form.Get("mark"), r.Form.Get("as")
+
+	id, err := strconv.ParseInt(r.Form.Get("id"), 10, 64)
+	if err != nil {
+		return api.BadRequest("invalid id '%s': must be an integer", r.Form.Get("id"))
+	}
+
+	if kind == "item" {
+		return srv.markItem(r, user, id, as)
+	}
+
+	if as != "read" {
+		return api.BadRequest("invalid as '%s': a %s can only be marked as read", as, kind)
+	}
+
+	var until sql.NullTime
+	if value := r.Form.Get("before"); value != "" {
+		before, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return api.BadRequest("invalid before '%s': must be a Unix timestamp", value)
+		}
+		until = sql.NullTime{Time: time.Unix(before, 0).UTC(), Valid: true}
+	}
+
+	feedIDs := []uuid.NullUUID{}
+	switch kind {
+	case "feed":
+		for _, follow := range follows {
+			if follow.Feed.ApiID == id {
+				feedIDs = append(feedIDs, uuid.NullUUID{UUID: follow.Feed.ID, Valid: true})
+			}
+		}
+	case "group":
+		if id == 0 {
+			feedIDs = append(feedIDs, uuid.NullUUID{})
+			break
+		}
+		for _, follow := range follows {
+			if follow.Category != "" && groupID(follow.Category) == id {
+				feedIDs = append(feedIDs, uuid.NullUUID{UUID: follow.Feed.ID, Valid: true})
+			}
+		}
+	default:
+		return api.BadRequest("invalid mark '%s': must be item, feed or group", kind)
+	}
+
+	return srv.store.InTx(r.Context(), func(q database.Querier) error {
+		for _, feedID := range feedIDs {
+			_, err := q.MarkAllPostsRead(r.Context(), database.MarkAllPostsReadParams{
+				UserID: user.ID,
+				FeedID: feedID,
+				Until:  until,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to mark posts as read: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (srv *Server) markItem(r *http.Request, user database.User, id int64, as string) error {
+	switch as {
+	case "read", "unread", "saved", "unsaved":
+	default:
+		return api.BadRequest("invalid as '%s': must be read, unread, saved or unsaved", as)
+	}
+
+	rows, err := srv.store.GetPostsForUserByApiIDs(r.Context(), database.GetPostsForUserByApiIDsParams{
+		UserID: user.ID,
+		ApiIds: []int64{id},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get posts for user '%s': %w", user.Name, err)
+	}
+
+	for _, row := range rows {
+		if as == "read" || as == "unread" {
+			err = srv.store.SetPostRead(r.Context(), database.SetPostReadParams{
+				UserID: user.ID,
+				PostID: row.Post.ID,
+				Read:   as == "read",
+			})
+		} else {
+			err = srv.store.SetPostStarred(r.Context(), database.SetPostStarredParams{
+				UserID:  user.ID,
+				PostID:  row.Post.ID,
+				Starred: as == "saved",
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to mark post '%s' as %s: %w", row.Post.ID, as, err)
+		}
+	}
+
+	return nil
+}
+
+func parseIDs(value string) ([]int64, error) {
+	ids := []int64{}
+	for _, field := range strings.Split(value, ",") {
+		id, err := strconv.ParseInt(strings.TrimSpace(field), 10, 64)
+		if err != nil {
+			return nil, api.BadRequest("invalid ID '%s': must be an integer", field)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func joinIDs(ids []int64) string {
+	fields := make([]string, len(ids))
+	for i, id := range ids {
+		fields[i] = strconv.FormatInt(id, 10)
+	}
+	return strings.Join(fields, ",")
+}
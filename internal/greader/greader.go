@@ -0,0 +1,183 @@
+// Package greader serves the subset of the Google Reader API that native
+// clients like Reeder and FeedMe sync with.
+package greader
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gator/internal/api"
+	"gator/internal/auth"
+	"gator/internal/database"
+	"net/http"
+	"strings"
+)
+
+const (
+	readingList = "user/-/state/com.google/reading-list"
+	readTag     = "user/-/state/com.google/read"
+	starredTag  = "user/-/state/com.google/starred"
+	labelPrefix = "user/-/label/"
+	feedPrefix  = "feed/"
+
+	// tokenPurpose derives the auth tokens from the API password, see
+	// auth.Token.
+	tokenPurpose = "greader"
+)
+
+type Server struct {
+	store api.Store
+	mux   *http.ServeMux
+}
+
+// NewServer routes the login under /accounts and the API under
+// /reader/api/0.
+func NewServer(store api.Store) *Server {
+	srv := &Server{store: store, mux: http.NewServeMux()}
+
+	srv.mux.HandleFunc("/accounts/ClientLogin", srv.clientLogin)
+	srv.mux.HandleFunc("GET /reader/api/0/token", srv.authed(srv.token))
+	srv.mux.HandleFunc("GET /reader/api/0/user-info", srv.authed(srv.userInfo))
+	srv.mux.HandleFunc("GET /reader/api/0/subscription/list", srv.authed(srv.subscriptions))
+	srv.mux.HandleFunc("GET /reader/api/0/tag/list", srv.authed(srv.tags))
+	srv.mux.HandleFunc("GET /reader/api/0/unread-count", srv.authed(srv.unreadCount))
+	srv.mux.HandleFunc("GET /reader/api/0/stream/contents", srv.authed(srv.streamContents))
+	srv.mux.HandleFunc("GET /reader/api/0/stream/contents/{stream...}", srv.authed(srv.streamContents))
+	srv.mux.HandleFunc("GET /reader/api/0/stream/items/ids", srv.authed(srv.streamItemIDs))
+	srv.mux.HandleFunc("/reader/api/0/stream/items/contents", srv.authed(srv.itemContents))
+	srv.mux.HandleFunc("POST /reader/api/0/edit-tag", srv.authed(srv.editTag))
+	srv.mux.HandleFunc("POST /reader/api/0/mark-all-as-read", srv.authed(srv.markAllAsRead))
+
+	return srv
+}
+
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	srv.mux.ServeHTTP(w, r)
+}
+
+// clientLogin checks the name and the API password of a user, set with
+// 'gator api-password', and hands out the token the other requests are
+// authenticated with.
+func (srv *Server) clientLogin(w http.ResponseWriter, r *http.Request) {
+	username := r.FormValue("Email")
+
+	user, err := srv.store.GetUser(r.Context(), username)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		api.WriteError(w, r, fmt.Errorf("failed to get user '%s': %w", username, err))
+		return
+	}
+
+	credential, err := srv.store.GetApiCredential(r.Context(), user.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+		return
+	}
+	if err != nil {
+		api.WriteError(w, r, fmt.Errorf("failed to get API credential of user '%s': %w", user.Name, err))
+		return
+	}
+
+	if !auth.CheckPassword(credential.PasswordHash, r.FormValue("Passwd")) {
+		http.Error(w, "Error=BadAuthentication", http.StatusUnauthorized)
+		return
+	}
+
+	token := user.Name + "/" + auth.Token(credential.PasswordHash, tokenPurpose)
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "SID=%s\nLSID=%s\nAuth=%s\n", token, token, token)
+}
+
+// authed authenticates a request by the 'GoogleLogin auth=<token>' header,
+// with a token returned by ClientLogin.
+func (srv *Server) authed(handler func(w http.ResponseWriter, r *http.Request, user database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "GoogleLogin auth=")
+		i := strings.LastIndex(token, "/")
+		if !ok || i < 0 {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		user, err := srv.store.GetUser(r.Context(), token[:i])
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			api.WriteError(w, r, fmt.Errorf("failed to get user '%s': %w", token[:i], err))
+			return
+		}
+
+		credential, err := srv.store.GetApiCredential(r.Context(), user.ID)
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			api.WriteError(w, r, fmt.Errorf("failed to get API credential of user '%s': %w", user.Name, err))
+			return
+		}
+
+		if !auth.CheckToken(credential.PasswordHash, tokenPurpose, token[i+1:]) {
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		handler(w, r, user)
+	}
+}
+
+// token returns the token clients send along with the edits. The requests
+// are already authenticated by their header, so it is not checked.
+func (srv *Server) token(w http.ResponseWriter, r *http.Request, user database.User) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintln(w, user.ID.String())
+}
+
+type userInfo struct {
+	UserID        string `json:"userId"`
+	UserName      string `json:"userName"`
+	UserProfileID string `json:"userProfileId"`
+	UserEmail     string `json:"userEmail"`
+}
+
+func (srv *Server) userInfo(w http.ResponseWriter, r *http.Request, user database.User) {
+	api.WriteJSON(w, http.StatusOK, userInfo{
+		UserID:        user.ID.String(),
+		UserName:      user.Name,
+		UserProfileID: user.ID.String(),
+	})
+}
+
+// follows returns the feeds followed by a user.
+func (srv *Server) follows(ctx context.Context, user database.User) ([]database.GetFeedFollowsByUserRow, error) {
+	follows, err := srv.store.GetFeedFollowsByUser(ctx, user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feeds for user '%s': %w", user.Name, err)
+	}
+	return follows, nil
+}
+
+// normalizeStream replaces the user of a stream ID by '-', the current user.
+func normalizeStream(stream string) string {
+	rest, ok := strings.CutPrefix(stream, "user/")
+	if !ok {
+		return stream
+	}
+
+	if _, tail, ok := strings.Cut(rest, "/"); ok {
+		return "user/-/" + tail
+	}
+	return stream
+}
+
+func writeOK(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprint(w, "OK")
+}
@@ -0,0 +1,489 @@
+package greader
+
+import (
+	"database/sql"
+	"fmt"
+	"gator/internal/api"
+	"gator/internal/cursor"
+	"gator/internal/database"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	itemPrefix    = "tag:google.com,2005:reader/item/"
+	keptUnreadTag = "user/-/state/com.google/kept-unread"
+
+	defaultCount = 20
+	maxCount     = 1000
+)
+
+type link struct {
+	Href string `json:"href"`
+	Type string `json:"type,omitempty"`
+}
+
+type content struct {
+	Direction string `json:"direction"`
+	Content   string `json:"content"`
+}
+
+type origin struct {
+	StreamID string `json:"streamId"`
+	Title    string `json:"title"`
+	HTMLURL  string `json:"htmlUrl"`
+}
+
+type item struct {
+	ID            string   `json:"id"`
+	CrawlTimeMsec string   `json:"crawlTimeMsec"`
+	TimestampUsec string   `json:"timestampUsec"`
+	Published     int64    `json:"published"`
+	Updated       int64    `json:"updated"`
+	Title         string   `json:"title"`
+	Canonical     []link   `json:"canonical"`
+	Alternate     []link   `json:"alternate"`
+	Summary       content  `json:"summary"`
+	Author        string   `json:"author,omitempty"`
+	Categories    []string `json:"categories"`
+	Origin        origin   `json:"origin"`
+}
+
+// itemID returns the long form of the ID of a post.
+func itemID(apiID int64) string {
+	return fmt.Sprintf("%s%016x", itemPrefix, apiID)
+}
+
+// parseItemID parses the long, hexadecimal, or the short, decimal, form of
+// the ID of an item.
+func parseItemID(s string) (int64, error) {
+	if hex, ok := strings.CutPrefix(s, itemPrefix); ok {
+		id, err := strconv.ParseUint(hex, 16, 64)
+		if err != nil {
+			return 0, api.BadRequest("invalid item ID '%s'", s)
+		}
+		return int64(id), nil
+	}
+
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil {
+		return 0, api.BadRequest("invalid item ID '%s'", s)
+	}
+	return id, nil
+}
+
+func parseItemIDs(values []string) ([]int64, error) {
+	ids := []int64{}
+	for _, value := range values {
+		id, err := parseItemID(value)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func newItem(post database.Post, read bool, starred bool, follows []database.GetFeedFollowsByUserRow) item {
+	crawledAt := post.PublishedAt
+	if post.CreatedAt.Valid {
+		crawledAt = post.CreatedAt.Time
+	}
+
+	it := item{
+		ID:            itemID(post.ApiID),
+		CrawlTimeMsec: strconv.FormatInt(crawledAt.UnixMilli(), 10),
+		TimestampUsec: strconv.FormatInt(post.PublishedAt.UnixMicro(), 10),
+		Published:     post.PublishedAt.Unix(),
+		Updated:       post.PublishedAt.Unix(),
+		Title:         post.Title,
+		Canonical:     []link{{Href: post.Url}},
+		Alternate:     []link{{Href: post.Url, Type: "text/html"}},
+		Summary:       content{Direction: "ltr", Content: post.Description},
+		Author:        post.Author,
+		Categories:    []string{readingList},
+	}
+
+	for _, follow := range follows {
+		if post.FeedID.Valid && follow.Feed.ID == post.FeedID.UUID {
+			it.Origin = origin{StreamID: feedPrefix + follow.Feed.Url, Title: follow.Feed.Name, HTMLURL: follow.Feed.Link}
+			if follow.Category != "" {
+				it.Categories = append(it.Categories, labelPrefix+follow.Category)
+			}
+		}
+	}
+	if read {
+		it.Categories = append(it.Categories, readTag)
+	}
+	if starred {
+		it.Categories = append(it.Categories, starredTag)
+	}
+
+	return it
+}
+
+// streamID returns the stream of a request, given in the path or as the s
+// parameter, the reading list by default.
+func streamID(r *http.Request) string {
+	stream := r.PathValue("stream")
+	if stream == "" {
+		stream = r.FormValue("s")
+	}
+	if stream == "" {
+		stream = readingList
+	}
+	return normalizeStream(stream)
+}
+
+// streamParams builds the query of the posts of a stream, paged by the n, c
+// and r parameters and filtered by the xt, ot and nt ones.
+func streamParams(r *http.Request, stream string, follows []database.GetFeedFollowsByUserRow) (cursor.PostsParams, error) {
+	params := cursor.PostsParams{
+		IncludeRead: true,
+		Limit:       defaultCount,
+	}
+
+	switch {
+	case stream == readingList:
+	case stream == starredTag:
+		// Starred posts are listed by streamPage from their states
+	case strings.HasPrefix(stream, labelPrefix):
+		params.Category = sql.NullString{String: strings.TrimPrefix(stream, labelPrefix), Valid: true}
+	case strings.HasPrefix(stream, feedPrefix):
+		feedID, err := followedFeedID(stream, follows)
+		if err != nil {
+			return params, err
+		}
+		params.FeedID = uuid.NullUUID{UUID: feedID, Valid: true}
+	default:
+		return params, api.BadRequest("unsupported stream '%s'", stream)
+	}
+
+	if value := r.FormValue("n"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 || n > maxCount {
+			return params, api.BadRequest("invalid n '%s': must be an integer between 1 and %d", value, maxCount)
+		}
+		params.Limit = int32(n)
+	}
+
+	if value := r.FormValue("c"); value != "" {
+		publishedAt, id, err := cursor.Decode(value)
+		if err != nil {
+			return params, api.BadRequest("%v", err)
+		}
+		params.CursorPublishedAt = sql.NullTime{Time: publishedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	params.OldestFirst = r.FormValue("r") == "o"
+
+	for _, excluded := range r.Form["xt"] {
+		if normalizeStream(excluded) == readTag {
+			params.IncludeRead = false
+		}
+	}
+
+	for name, target := range map[string]*sql.NullTime{"ot": &params.Since, "nt": &params.Until} {
+		if value := r.FormValue(name); value != "" {
+			seconds, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return params, api.BadRequest("invalid %s '%s': must be a Unix timestamp", name, value)
+			}
+			*target = sql.NullTime{Time: time.Unix(seconds, 0).UTC(), Valid: true}
+		}
+	}
+
+	return params, nil
+}
+
+// followedFeedID returns the ID of the followed feed of a feed/<url> stream.
+func followedFeedID(stream string, follows []database.GetFeedFollowsByUserRow) (uuid.UUID, error) {
+	url := strings.TrimPrefix(stream, feedPrefix)
+	for _, follow := range follows {
+		if follow.Feed.Url == url {
+			return follow.Feed.ID, nil
+		}
+	}
+	return uuid.Nil, api.BadRequest("not following feed '%s'", url)
+}
+
+// streamPage is a page of the posts of a stream, with the continuation of
+// the next page when it is full.
+type streamPage struct {
+	ID           string
+	Rows         []cursor.PostRow
+	Follows      []database.GetFeedFollowsByUserRow
+	Continuation string
+}
+
+func (srv *Server) streamPage(r *http.Request, user database.User) (streamPage, error) {
+	page := streamPage{ID: streamID(r)}
+
+	follows, err := srv.follows(r.Context(), user)
+	if err != nil {
+		return page, err
+	}
+	page.Follows = follows
+
+	params, err := streamParams(r, page.ID, follows)
+	if err != nil {
+		return page, err
+	}
+	params.ID = user.ID
+
+	if page.ID == starredTag {
+		page.Rows, err = cursor.StarredPosts(r.Context(), srv.store, params)
+	} else {
+		page.Rows, err = cursor.Posts(r.Context(), srv.store, params)
+	}
+	if err != nil {
+		return page, fmt.Errorf("failed to get posts for user '%s': %w", user.Name, err)
+	}
+
+	if len(page.Rows) == int(params.Limit) {
+		last := page.Rows[len(page.Rows)-1].Post
+		page.Continuation = cursor.Encode(last.PublishedAt, last.ID)
+	}
+
+	return page, nil
+}
+
+type streamContents struct {
+	ID           string `json:"id"`
+	Updated      int64  `json:"updated"`
+	Items        []item `json:"items"`
+	Continuation string `json:"continuation,omitempty"`
+}
+
+func (srv *Server) streamContents(w http.ResponseWriter, r *http.Request, user database.User) {
+	page, err := srv.streamPage(r, user)
+	if err != nil {
+		api.WriteError(w, r, err)
+		return
+	}
+
+	contents := streamContents{
+		ID:           page.ID,
+		Updated:      time.Now().Unix(),
+		Items:        []item{},
+		Continuation: page.Continuation,
+	}
+	for _, row := range page.Rows {
+		contents.Items = append(contents.Items, newItem(row.Post, row.Read, row.Starred, page.Follows))
+	}
+
+	api.WriteJSON(w, http.StatusOK, contents)
+}
+
+type itemRef struct {
+	ID            string `json:"id"`
+	TimestampUsec string `json:"timestampUsec"`
+}
+
+type itemRefs struct {
+	ItemRefs     []itemRef `json:"itemRefs"`
+	Continuation string    `json:"continuation,omitempty"`
+}
+
+// streamItemIDs lists the short IDs of the items of a stream, which clients
+// then fetch with itemContents.
+func (srv *Server) streamItemIDs(w http.ResponseWriter, r *http.Request, user database.User) {
+	page, err := srv.streamPage(r, user)
+	if err != nil {
+		api.WriteError(w, r, err)
+		return
+	}
+
+	refs := itemRefs{ItemRefs: []itemRef{}, Continuation: page.Continuation}
+	for _, row := range page.Rows {
+		refs.ItemRefs = append(refs.ItemRefs, itemRef{
+			ID:            strconv.FormatInt(row.Post.ApiID, 10),
+			TimestampUsec: strconv.FormatInt(row.Post.PublishedAt.UnixMicro(), 10),
+		})
+	}
+
+	api.WriteJSON(w, http.StatusOK, refs)
+}
+
+// itemContents returns the items of the i parameters.
+func (srv *Server) itemContents(w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ids, err := parseItemIDs(r.Form["i"])
+	if err != nil {
+		api.WriteError(w, r, err)
+		return
+	}
+
+	follows, err := srv.follows(r.Context(), user)
+	if err != nil {
+		api.WriteError(w, r, err)
+		return
+	}
+
+	rows, err := srv.store.GetPostsForUserByApiIDs(r.Context(), database.GetPostsForUserByApiIDsParams{
+		UserID: user.ID,
+		ApiIds: ids,
+	})
+	if err != nil {
+		api.WriteError(w, r, fmt.Errorf("failed to get posts for user '%s': %w", user.Name, err))
+		return
+	}
+
+	contents := streamContents{
+		ID:      readingList,
+		Updated: time.Now().Unix(),
+		Items:   []item{},
+	}
+	for _, row := range rows {
+		contents.Items = append(contents.Items, newItem(row.Post, row.Read, row.Starred, follows))
+	}
+
+	api.WriteJSON(w, http.StatusOK, contents)
+}
+
+// editTag adds the a tags to the items of the i parameters and removes the
+// r ones. Only the read, kept-unread and starred states are supported.
+func (srv *Server) editTag(w http.ResponseWriter, r *http.Request, user database.User) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	ids, err := parseItemIDs(r.Form["i"])
+	if err != nil {
+		api.WriteError(w, r, err)
+		return
+	}
+
+	err = srv.store.InTx(r.Context(), func(q database.Querier) error {
+		rows, err := q.GetPostsForUserByApiIDs(r.Context(), database.GetPostsForUserByApiIDsParams{
+			UserID: user.ID,
+			ApiIds: ids,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to get posts for user '%s': %w", user.Name, err)
+		}
+
+		for _, row := range rows {
+			for _, tag := range r.Form["a"] {
+				if err := setTag(r, q, user, row.Post, tag, true); err != nil {
+					return err
+				}
+			}
+			for _, tag := range r.Form["r"] {
+				if err := setTag(r, q, user, row.Post, tag, false); err != nil {
+					return err
+				}
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		api.WriteError(w, r, err)
+		return
+	}
+
+	writeOK(w)
+}
+
+func setTag(r *http.Request, q database.Querier, user database.User, post database.Post, tag string, value bool) error {
+	switch normalizeStream(tag) {
+	case readTag, keptUnreadTag:
+		read := value
+		if normalizeStream(tag) == keptUnreadTag {
+			read = !value
+		}
+
+		err := q.SetPostRead(r.Context(), database.SetPostReadParams{
+			UserID: user.ID,
+			PostID: post.ID,
+			Read:   read,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to mark post '%s' as read: %w", post.ID, err)
+		}
+	case starredTag:
+		err := q.SetPostStarred(r.Context(), database.SetPostStarredParams{
+			UserID:  user.ID,
+			PostID:  post.ID,
+			Starred: value,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to star post '%s': %w", post.ID, err)
+		}
+	}
+
+	return nil
+}
+
+// markAllAsRead marks the posts of the s stream as read, up to the ts
+// parameter in microseconds when given.
+func (srv *Server) markAllAsRead(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := srv.follows(r.Context(), user)
+	if err != nil {
+		api.WriteError(w, r, err)
+		return
+	}
+
+	var until sql.NullTime
+	if value := r.FormValue("ts"); value != "" {
+		usec, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			api.WriteError(w, r, api.BadRequest("invalid ts '%s': must be a timestamp in microseconds", value))
+			return
+		}
+		until = sql.NullTime{Time: time.UnixMicro(usec).UTC(), Valid: true}
+	}
+
+	feeds := []uuid.NullUUID{}
+	switch stream := normalizeStream(r.FormValue("s")); {
+	case stream == readingList:
+		feeds = append(feeds, uuid.NullUUID{})
+	case strings.HasPrefix(stream, labelPrefix):
+		for _, follow := range follows {
+			if follow.Category == strings.TrimPrefix(stream, labelPrefix) {
+				feeds = append(feeds, uuid.NullUUID{UUID: follow.Feed.ID, Valid: true})
+			}
+		}
+	case strings.HasPrefix(stream, feedPrefix):
+		feedID, err := followedFeedID(stream, follows)
+		if err != nil {
+			api.WriteError(w, r, err)
+			return
+		}
+		feeds = append(feeds, uuid.NullUUID{UUID: feedID, Valid: true})
+	default:
+		api.WriteError(w, r, api.BadRequest("unsupported stream '%s'", stream))
+		return
+	}
+
+	err = srv.store.InTx(r.Context(), func(q database.Querier) error {
+		for _, feedID := range feeds {
+			_, err := q.MarkAllPostsRead(r.Context(), database.MarkAllPostsReadParams{
+				UserID: user.ID,
+				FeedID: feedID,
+				Until:  until,
+			})
+			if err != nil {
+				return fmt.Errorf("failed to mark posts as read: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		api.WriteError(w, r, err)
+		return
+	}
+
+	writeOK(w)
+}
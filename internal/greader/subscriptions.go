@@ -0,0 +1,161 @@
+package greader
+
+import (
+	"fmt"
+	"gator/internal/api"
+	"gator/internal/database"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+)
+
+type category struct {
+	ID    string `json:"id"`
+	Label string `json:"label"`
+}
+
+type subscription struct {
+	ID         string     `json:"id"`
+	Title      string     `json:"title"`
+	Categories []category `json:"categories"`
+	URL        string     `json:"url"`
+	HTMLURL    string     `json:"htmlUrl"`
+	IconURL    string     `json:"iconUrl"`
+}
+
+// subscriptions lists the feeds followed by the user, filed under their
+// category as a label.
+func (srv *Server) subscriptions(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := srv.follows(r.Context(), user)
+	if err != nil {
+		api.WriteError(w, r, err)
+		return
+	}
+
+	subscriptions := []subscription{}
+	for _, follow := range follows {
+		sub := subscription{
+			ID:         feedPrefix + follow.Feed.Url,
+			Title:      follow.Feed.Name,
+			Categories: []category{},
+			URL:        follow.Feed.Url,
+			HTMLURL:    follow.Feed.Link,
+		}
+		if follow.Category != "" {
+			sub.Categories = append(sub.Categories, category{ID: labelPrefix + follow.Category, Label: follow.Category})
+		}
+		subscriptions = append(subscriptions, sub)
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string][]subscription{"subscriptions": subscriptions})
+}
+
+type tag struct {
+	ID   string `json:"id"`
+	Type string `json:"type,omitempty"`
+}
+
+// tags lists the starred state and the categories of the followed feeds.
+func (srv *Server) tags(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := srv.follows(r.Context(), user)
+	if err != nil {
+		api.WriteError(w, r, err)
+		return
+	}
+
+	tags := []tag{{ID: starredTag}}
+	for _, label := range labels(follows) {
+		tags = append(tags, tag{ID: labelPrefix + label, Type: "folder"})
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string][]tag{"tags": tags})
+}
+
+// labels returns the distinct categories of the followed feeds, sorted.
+func labels(follows []database.GetFeedFollowsByUserRow) []string {
+	seen := map[string]bool{}
+	labels := []string{}
+	for _, follow := range follows {
+		if follow.Category != "" && !seen[follow.Category] {
+			seen[follow.Category] = true
+			labels = append(labels, follow.Category)
+		}
+	}
+
+	sort.Strings(labels)
+	return labels
+}
+
+type unreadCount struct {
+	ID                      string `json:"id"`
+	Count                   int64  `json:"count"`
+	NewestItemTimestampUsec string `json:"newestItemTimestampUsec"`
+}
+
+// unreadCount counts the unread posts of each followed feed, of each label
+// and of the whole reading list.
+func (srv *Server) unreadCount(w http.ResponseWriter, r *http.Request, user database.User) {
+	follows, err := srv.follows(r.Context(), user)
+	if err != nil {
+		api.WriteError(w, r, err)
+		return
+	}
+
+	rows, err := srv.store.GetUnreadCountsForUser(r.Context(), user.ID)
+	if err != nil {
+		api.WriteError(w, r, fmt.Errorf("failed to count unread posts for user '%s': %w", user.Name, err))
+		return
+	}
+
+	type total struct {
+		count  int64
+		newest time.Time
+	}
+	totals := map[string]*total{}
+	add := func(id string, row database.GetUnreadCountsForUserRow) {
+		t, ok := totals[id]
+		if !ok {
+			t = &total{}
+			totals[id] = t
+		}
+		t.count += row.Count
+		if row.NewestPublishedAt.After(t.newest) {
+			t.newest = row.NewestPublishedAt
+		}
+	}
+
+	for _, row := range rows {
+		for _, follow := range follows {
+			if row.FeedID.Valid && follow.Feed.ID == row.FeedID.UUID {
+				add(feedPrefix+follow.Feed.Url, row)
+				if follow.Category != "" {
+					add(labelPrefix+follow.Category, row)
+				}
+				add(readingList, row)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(totals))
+	for id := range totals {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	counts := []unreadCount{}
+	for _, id := range ids {
+		counts = append(counts, unreadCount{
+			ID:                      id,
+			Count:                   totals[id].count,
+			NewestItemTimestampUsec: strconv.FormatInt(totals[id].newest.UnixMicro(), 10),
+		})
+	}
+
+	var unread int64
+	if t, ok := totals[readingList]; ok {
+		unread = t.count
+	}
+
+	api.WriteJSON(w, http.StatusOK, map[string]any{"max": unread, "unreadcounts": counts})
+}
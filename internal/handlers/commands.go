@@ -0,0 +1,337 @@
+package handlers
+
+import (
+	"fmt"
+	"gator/internal/output"
+	"gator/internal/state"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+type Command struct {
+	Name string
+	Args []string
+	// Flags holds the values of the flags given on the command line, keyed
+	// by name without the leading dashes. Boolean flags are set to "true".
+	Flags map[string]string
+	// Output is the format listing commands render their rows in.
+	Output output.Format
+}
+
+// Flag returns the value of a string flag and whether it was given.
+func (c Command) Flag(name string) (string, bool) {
+	value, ok := c.Flags[name]
+	return value, ok
+}
+
+// Bool reports whether a boolean flag was given.
+func (c Command) Bool(name string) bool {
+	_, ok := c.Flags[name]
+	return ok
+}
+
+// Int returns the value of an integer flag and whether it was given. The
+// value has already been validated by Commands.Run.
+func (c Command) Int(name string) (int, bool) {
+	value, ok := c.Flags[name]
+	if !ok {
+		return 0, false
+	}
+
+	n, _ := strconv.Atoi(value)
+	return n, true
+}
+
+type FlagType int
+
+const (
+	StringFlag FlagType = iota
+	IntFlag
+	BoolFlag
+)
+
+// Completer returns the candidates offered by the shell completion for the
+// value of an argument or a flag.
+type Completer func(s *state.State) ([]string, error)
+
+// Flag describes a --name flag accepted by a command. Value is the
+// placeholder shown in the usage of string and integer flags.
+type Flag struct {
+	Name        string
+	Type        FlagType
+	Value       string
+	Description string
+	Complete    Completer
+}
+
+func (f Flag) usage() string {
+	if f.Type == BoolFlag {
+		return "--" + f.Name
+	}
+	return fmt.Sprintf("--%s <%s>", f.Name, f.Value)
+}
+
+// Arg describes a positional argument of a command. A variadic argument
+// takes all the remaining ones and must come last.
+type Arg struct {
+	Name     string
+	Optional bool
+	Variadic bool
+	Complete Completer
+}
+
+func (a Arg) usage() string {
+	usage := a.Name
+	if a.Variadic {
+		usage += "..."
+	}
+	if a.Optional {
+		return "[" + usage + "]"
+	}
+	return "<" + usage + ">"
+}
+
+// Spec describes a command: how it is invoked, what it does and which
+// arguments and flags it accepts, so that its usage, its help and the
+// validation of its arguments are generated uniformly.
+type Spec struct {
+	Name        string
+	Aliases     []string
+	Description string
+	Args        []Arg
+	Flags       []Flag
+	// Hidden commands are left out of 'gator help'.
+	Hidden bool
+	// SkipSchemaCheck runs the command even when the database schema is not
+	// at the expected version.
+	SkipSchemaCheck bool
+	Handler         func(*state.State, Command) error
+}
+
+// Usage returns the one-line synopsis of the command.
+func (spec *Spec) Usage() string {
+	usage := "gator " + spec.Name
+	for _, flag := range spec.Flags {
+		usage += " [" + flag.usage() + "]"
+	}
+	for _, arg := range spec.Args {
+		usage += " " + arg.usage()
+	}
+	return usage
+}
+
+// flag looks up a flag of the command, or a global flag.
+func (spec *Spec) flag(name string) (Flag, bool) {
+	for _, flag := range spec.Flags {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	for _, flag := range globalFlags {
+		if flag.Name == name {
+			return flag, true
+		}
+	}
+	return Flag{}, false
+}
+
+// globalFlags are accepted by every command.
+var globalFlags = []Flag{
+	{Name: "output", Type: StringFlag, Value: "format", Description: "print listings as text, json, csv or tsv",
+		Complete: Values(string(output.Text), string(output.JSON), string(output.CSV), string(output.TSV))},
+	{Name: "help", Type: BoolFlag, Description: "show the help of the command"},
+}
+
+type Commands struct {
+	// Cmds holds the registered commands by name and by alias.
+	Cmds map[string]*Spec
+	// CheckSchema, when set, is called before running the commands that do
+	// not skip the schema check.
+	CheckSchema func(*state.State) error
+}
+
+func (c *Commands) Register(spec Spec) {
+	c.Cmds[spec.Name] = &spec
+	for _, alias := range spec.Aliases {
+		c.Cmds[alias] = &spec
+	}
+}
+
+func (c *Commands) Run(s *state.State, cmd Command) error {
+	spec, ok := c.Cmds[cmd.Name]
+	if !ok {
+		return fmt.Errorf("unknown command '%s', see 'gator help'", cmd.Name)
+	}
+	cmd.Name = spec.Name
+
+	cmd, err := parseArgs(spec, cmd)
+	if err != nil {
+		return err
+	}
+
+	if cmd.Bool("help") {
+		spec.writeHelp(os.Stdout)
+		return nil
+	}
+
+	if c.CheckSchema != nil && !spec.SkipSchemaCheck {
+		if err := c.CheckSchema(s); err != nil {
+			return err
+		}
+	}
+
+	return spec.Handler(s, cmd)
+}
+
+// parseArgs splits the arguments of a command into its flags and its
+// positional arguments, and validates both against the spec.
+func parseArgs(spec *Spec, cmd Command) (Command, error) {
+	cmd.Flags = map[string]string{}
+	cmd.Output = output.Text
+
+	args := []string{}
+	for i := 0; i < len(cmd.Args); i++ {
+		arg := cmd.Args[i]
+
+		if arg == "--" {
+			args = append(args, cmd.Args[i+1:]...)
+			break
+		}
+		if arg == "-h" {
+			arg = "--help"
+		}
+		if !strings.HasPrefix(arg, "--") {
+			args = append(args, arg)
+			continue
+		}
+
+		name, value, hasValue := strings.Cut(strings.TrimPrefix(arg, "--"), "=")
+
+		flag, ok := spec.flag(name)
+		if !ok {
+			return cmd, fmt.Errorf("unknown flag '--%s' for command '%s', see 'gator %s --help'", name, spec.Name, spec.Name)
+		}
+
+		if flag.Type == BoolFlag {
+			if hasValue {
+				return cmd, fmt.Errorf("flag '--%s' does not take a value", name)
+			}
+			cmd.Flags[name] = "true"
+			continue
+		}
+
+		if !hasValue {
+			if i+1 >= len(cmd.Args) {
+				return cmd, fmt.Errorf("missing value for flag '--%s'", name)
+			}
+			i++
+			value = cmd.Args[i]
+		}
+
+		if flag.Type == IntFlag {
+			if _, err := strconv.Atoi(value); err != nil {
+				return cmd, fmt.Errorf("invalid value '%s' for flag '--%s': must be an integer", value, name)
+			}
+		}
+
+		cmd.Flags[name] = value
+	}
+	cmd.Args = args
+
+	// Help is shown whatever the other arguments are
+	if cmd.Bool("help") {
+		return cmd, nil
+	}
+
+	if value, ok := cmd.Flag("output"); ok {
+		format, err := output.ParseFormat(value)
+		if err != nil {
+			return cmd, err
+		}
+		cmd.Output = format
+	}
+
+	min, max := 0, 0
+	for _, arg := range spec.Args {
+		if !arg.Optional {
+			min++
+		}
+		max++
+		if arg.Variadic {
+			max = len(cmd.Args)
+		}
+	}
+	if len(cmd.Args) < min || len(cmd.Args) > max {
+		return cmd, fmt.Errorf("usage: %s", spec.Usage())
+	}
+
+	return cmd, nil
+}
+
+func (spec *Spec) writeHelp(w io.Writer) {
+	fmt.Fprintf(w, "Usage: %s\n\n", spec.Usage())
+	fmt.Fprintf(w, "%s\n", spec.Description)
+
+	if len(spec.Aliases) > 0 {
+		fmt.Fprintf(w, "\nAliases: %s\n", strings.Join(spec.Aliases, ", "))
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if len(spec.Flags) > 0 {
+		fmt.Fprintf(tw, "\nFlags:\n")
+		for _, flag := range spec.Flags {
+			fmt.Fprintf(tw, "  %s\t%s\n", flag.usage(), flag.Description)
+		}
+	}
+	fmt.Fprintf(tw, "\nGlobal flags:\n")
+	for _, flag := range globalFlags {
+		fmt.Fprintf(tw, "  %s\t%s\n", flag.usage(), flag.Description)
+	}
+	tw.Flush()
+}
+
+// Help prints the list of commands, or the help of the command given as
+// argument.
+func (c *Commands) Help(s *state.State, cmd Command) error {
+	if len(cmd.Args) > 0 {
+		spec, ok := c.Cmds[cmd.Args[0]]
+		if !ok {
+			return fmt.Errorf("unknown command '%s', see 'gator help'", cmd.Args[0])
+		}
+		spec.writeHelp(os.Stdout)
+		return nil
+	}
+
+	fmt.Printf("Usage: gator <command> [flags] [args]\n\nCommands:\n")
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	for _, spec := range c.Specs() {
+		fmt.Fprintf(tw, "  %s\t%s\n", spec.Name, spec.Description)
+	}
+	tw.Flush()
+
+	fmt.Printf("\nRun 'gator <command> --help' for the usage of a command.\n")
+
+	return nil
+}
+
+// Specs returns the visible commands sorted by name, without their aliases.
+func (c *Commands) Specs() []*Spec {
+	specs := []*Spec{}
+	for name, spec := range c.Cmds {
+		if name != spec.Name || spec.Hidden {
+			continue
+		}
+		specs = append(specs, spec)
+	}
+
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+
+	return specs
+}
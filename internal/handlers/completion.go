@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"gator/internal/state"
+	"strings"
+)
+
+// Values returns a Completer offering a fixed set of values.
+func Values(values ...string) Completer {
+	return func(s *state.State) ([]string, error) {
+		return values, nil
+	}
+}
+
+func CompleteUsernames(s *state.State) ([]string, error) {
+	users, err := s.Queries.GetUsers(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users: %w", err)
+	}
+
+	names := []string{}
+	for _, user := range users {
+		names = append(names, user.Name)
+	}
+
+	return names, nil
+}
+
+func CompleteFeedURLs(s *state.State) ([]string, error) {
+	rows, err := s.Queries.GetAllFeedsWithUsers(context.Background())
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feeds: %w", err)
+	}
+
+	urls := []string{}
+	for _, row := range rows {
+		urls = append(urls, row.Feed.Url)
+	}
+
+	return urls, nil
+}
+
+// CompleteFollowedFeedURLs offers the URLs of the feeds followed by the
+// current user.
+func CompleteFollowedFeedURLs(s *state.State) ([]string, error) {
+	user, err := s.Queries.GetUser(context.Background(), s.Cfg.CurrentUserName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	rows, err := s.Queries.GetFeedFollowsByUser(context.Background(), user.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feeds for user '%s': %w", user.Name, err)
+	}
+
+	urls := []string{}
+	for _, row := range rows {
+		urls = append(urls, row.Feed.Url)
+	}
+
+	return urls, nil
+}
+
+// Complete is the hidden command called by the completion scripts. Its
+// arguments are the words of the command line after 'gator', the last one
+// being the word to complete, and it prints one candidate per line.
+func (c *Commands) Complete(s *state.State, cmd Command) error {
+	words := cmd.Args
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current := words[len(words)-1]
+
+	candidates, err := c.candidates(s, words)
+	if err != nil {
+		return err
+	}
+
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			fmt.Println(candidate)
+		}
+	}
+
+	return nil
+}
+
+func (c *Commands) candidates(s *state.State, words []string) ([]string, error) {
+	if len(words) == 1 {
+		names := []string{}
+		for _, spec := range c.Specs() {
+			names = append(names, spec.Name)
+		}
+		return names, nil
+	}
+
+	spec, ok := c.Cmds[words[0]]
+	if !ok {
+		return nil, nil
+	}
+	current := words[len(words)-1]
+
+	if strings.HasPrefix(current, "-") {
+		names := []string{}
+		for _, flag := range spec.Flags {
+			names = append(names, "--"+flag.Name)
+		}
+		for _, flag := range globalFlags {
+			names = append(names, "--"+flag.Name)
+		}
+		return names, nil
+	}
+
+	// Count the positional arguments before the current word, skipping the
+	// flags and their values
+	position := 0
+	for i := 1; i < len(words)-1; i++ {
+		word := words[i]
+		if !strings.HasPrefix(word, "--") {
+			position++
+			continue
+		}
+
+		flag, ok := spec.flag(strings.TrimPrefix(word, "--"))
+		if !ok || flag.Type == BoolFlag {
+			continue
+		}
+
+		if i == len(words)-2 {
+			// The current word is the value of the flag
+			if flag.Complete == nil {
+				return nil, nil
+			}
+			return flag.Complete(s)
+		}
+		i++
+	}
+
+	if len(spec.Args) == 0 {
+		return nil, nil
+	}
+
+	arg := spec.Args[len(spec.Args)-1]
+	if position < len(spec.Args) {
+		arg = spec.Args[position]
+	} else if !arg.Variadic {
+		return nil, nil
+	}
+
+	if arg.Complete == nil {
+		return nil, nil
+	}
+
+	return arg.Complete(s)
+}
+
+const bashCompletion = `# bash completion for gator, load with: source <(gator completion bash)
+_gator() {
+    local cur words cword
+    if declare -F _get_comp_words_by_ref >/dev/null; then
+        # Keep the URLs whole instead of breaking them on colons
+        _get_comp_words_by_ref -n =: cur words cword
+    else
+        cur="${COMP_WORDS[COMP_CWORD]}"
+        words=("${COMP_WORDS[@]}")
+        cword=$COMP_CWORD
+    fi
+
+    local IFS=$'\n'
+    COMPREPLY=($(gator __complete -- "${words[@]:1:cword}" 2>/dev/null))
+
+    if declare -F __ltrim_colon_completions >/dev/null; then
+        __ltrim_colon_completions "$cur"
+    fi
+}
+complete -o default -F _gator gator
+`
+
+const zshCompletion = `#compdef gator
+# zsh completion for gator, load with: source <(gator completion zsh)
+_gator() {
+    local -a candidates
+    candidates=("${(@f)$(gator __complete -- "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    candidates=(${candidates:#})
+
+    if (( ${#candidates} )); then
+        compadd -- "${candidates[@]}"
+    else
+        _files
+    fi
+}
+compdef _gator gator
+`
+
+const fishCompletion = `# fish completion for gator, load with: gator completion fish | source
+function __gator_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    gator __complete -- $tokens[2..-1] 2>/dev/null
+end
+complete -c gator -f -a '(__gator_complete)'
+`
+
+// Completion prints the completion script for the given shell. The scripts
+// delegate to the hidden __complete command, so that they follow the
+// registered commands and can offer values from the database.
+func (c *Commands) Completion(s *state.State, cmd Command) error {
+	switch cmd.Args[0] {
+	case "bash":
+		fmt.Print(bashCompletion)
+	case "zsh":
+		fmt.Print(zshCompletion)
+	case "fish":
+		fmt.Print(fishCompletion)
+	default:
+		return fmt.Errorf("unsupported shell '%s': must be bash, zsh or fish", cmd.Args[0])
+	}
+
+	return nil
+}
@@ -1,164 +1,405 @@
 package handlers
 
 import (
+	"bufio"
 	"context"
+	"crypto/rand"
+	"database/sql"
+	"errors"
 	"fmt"
+	"gator/internal/api"
+	"gator/internal/auth"
+	"gator/internal/config"
+	"gator/internal/cursor"
 	"gator/internal/database"
+	"gator/internal/feeds"
+	"gator/internal/fever"
+	"gator/internal/greader"
+	"gator/internal/migrate"
+	"gator/internal/opml"
+	"gator/internal/output"
+	"gator/internal/publish"
+	"gator/internal/rss"
 	"gator/internal/scraper"
 	"gator/internal/state"
-	"net/url"
+	"gator/internal/web"
+	"gator/sql/schema"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
 	"strconv"
+	"strings"
+	"syscall"
 	"time"
-)
-
-type Command struct {
-	Name string
-	Args []string
-}
-
-type Commands struct {
-	Cmds map[string]func(*state.State, Command) error
-}
-
-func (c *Commands) Run(s *state.State, cmd Command) error {
-	f, ok := c.Cmds[cmd.Name]
-	if !ok {
-		return fmt.Errorf("unknown command '%s'", cmd.Name)
-	}
-	return f(s, cmd)
-}
 
-func (c *Commands) Register(name string, f func(*state.State, Command) error) {
-	c.Cmds[name] = f
-}
+	"github.com/google/uuid"
+)
 
 func AddFeed(s *state.State, cmd Command, user database.User) error {
-	if len(cmd.Args) < 2 {
-		return fmt.Errorf("usage: gator addFeed <feed name> <feed url>")
-	}
-
 	tx, err := s.Db.Begin()
 	if err != nil {
 		return fmt.Errorf("failed to begin transaction: %w", err)
 	}
 	defer tx.Rollback()
 
-	url, err := url.Parse(cmd.Args[1])
+	feed, err := feeds.Add(context.Background(), s.Queries.WithTx(tx), user, cmd.Args[0], cmd.Args[1])
 	if err != nil {
-		return fmt.Errorf("failed to parse URL '%s': %w", cmd.Args[1], err)
-	}
-
-	qtx := s.Queries.WithTx(tx)
-
-	feed, err := qtx.CreateFeed(context.Background(),
-		database.CreateFeedParams{
-			Name:   cmd.Args[0],
-			Url:    url.String(),
-			UserID: user.ID,
-		})
-	if err != nil {
-		return fmt.Errorf("failed to create feed: %w", err)
-	}
-
-	feedFollow, err := qtx.CreateFeedFollow(context.Background(),
-		database.CreateFeedFollowParams{
-			FeedID: feed.ID,
-			UserID: user.ID,
-		})
-	if err != nil {
-		return fmt.Errorf("failed to follow feed: %w", err)
+		return err
 	}
 
 	if err := tx.Commit(); err != nil {
 		return fmt.Errorf("failed to commit transaction: %w", err)
 	}
 
-	fmt.Printf("feed '%s' with URL '%s' was added and followed by user '%s'\n", feedFollow.Feed.Name, feedFollow.Feed.Url, feedFollow.User.Name)
+	fmt.Printf("feed '%s' with URL '%s' was added and followed by user '%s'\n", feed.Name, feed.Url, user.Name)
 
 	return nil
 }
 
-func Agg(s *state.State, cmd Command) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("usage: gator agg <time between reqs>")
-	}
+// aggDrainTimeout is how long agg waits for in-flight fetches on shutdown.
+const aggDrainTimeout = 10 * time.Second
 
+func Agg(s *state.State, cmd Command) error {
 	timeBetweenReqsStr := cmd.Args[0]
 	timeBetweenReqs, err := time.ParseDuration(timeBetweenReqsStr)
 	if err != nil {
 		return fmt.Errorf("failed to parse time duration '%s': %w", timeBetweenReqsStr, err)
 	}
 
-	fmt.Printf("Collecting feeds every %s\n", timeBetweenReqs)
+	concurrency := 1
+	if len(cmd.Args) > 1 {
+		concurrencyStr := cmd.Args[1]
+
+		concurrency, err = strconv.Atoi(concurrencyStr)
+		if err != nil || concurrency < 1 {
+			return fmt.Errorf("invalid concurrency '%s': must be a positive integer", concurrencyStr)
+		}
+	}
+
+	// ctx is cancelled on SIGINT or SIGTERM to stop claiming new feeds, while
+	// in-flight fetches run on workCtx until the drain timeout expires
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	workCtx, cancelWork := context.WithCancel(context.Background())
+	defer cancelWork()
+
+	context.AfterFunc(ctx, func() {
+		// Restore the default behavior so that a second signal kills agg
+		stop()
+		fmt.Printf("Shutting down, waiting up to %s for in-flight fetches\n", aggDrainTimeout)
+		time.AfterFunc(aggDrainTimeout, cancelWork)
+	})
+
+	fmt.Printf("Collecting up to %d feeds every %s\n", concurrency, timeBetweenReqs)
+
+	start := time.Now()
+	total := scraper.Stats{}
 
 	ticker := time.NewTicker(timeBetweenReqs)
-	for ; ; <-ticker.C {
-		if err := scraper.ScrapeFeeds(s); err != nil {
-			return fmt.Errorf("error scraping feeds: %v\n", err)
+	defer ticker.Stop()
+
+	for {
+		// Failing feeds are backed off by the scraper, so the aggregator
+		// keeps running
+		stats, err := scraper.ScrapeFeeds(workCtx, s, concurrency)
+		total.Add(stats)
+		if err != nil {
+			fmt.Printf("error scraping feeds: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			fmt.Printf("Aggregated for %s: %d feeds fetched, %d failed, %d new, %d updated and %d unchanged posts\n",
+				time.Since(start).Round(time.Second), total.Feeds, total.Failures, total.New, total.Updated, total.Unchanged)
+			return nil
+		case <-ticker.C:
 		}
 	}
 }
 
+// ApiPassword sets the password the current user signs in to the sync APIs
+// with. It is read from the standard input so that it stays out of the shell
+// history.
+func ApiPassword(s *state.State, cmd Command, user database.User) error {
+	fmt.Fprint(os.Stderr, "API password: ")
+
+	password, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to read password: %w", err)
+	}
+	password = strings.TrimRight(password, "\r\n")
+	if password == "" {
+		return errors.New("password cannot be empty")
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		return err
+	}
+
+	err = s.Queries.SetApiPassword(context.Background(), database.SetApiPasswordParams{
+		UserID:       user.ID,
+		PasswordHash: hash,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set API password for user '%s': %w", user.Name, err)
+	}
+
+	fmt.Printf("API password of '%s' was set\n", user.Name)
+
+	return nil
+}
+
+// FeverPassword generates the password the current user signs in to the
+// Fever API with. Fever clients only send the unsalted MD5 of the username
+// and password, so it is a random password rather than the API one.
+func FeverPassword(s *state.State, cmd Command, user database.User) error {
+	password := rand.Text()
+
+	err := s.Queries.SetFeverApiKey(context.Background(), database.SetFeverApiKeyParams{
+		UserID: user.ID,
+		ApiKey: fever.APIKey(user.Name, password),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set Fever password for user '%s': %w", user.Name, err)
+	}
+
+	fmt.Printf("Fever password of '%s': %s\n", user.Name, password)
+
+	return nil
+}
+
 func Browse(s *state.State, cmd Command, user database.User) error {
-	limit := 2
+	params := cursor.PostsParams{
+		ID:          user.ID,
+		IncludeRead: cmd.Bool("all") && !cmd.Bool("unread"),
+		Limit:       2,
+	}
 
 	if len(cmd.Args) > 0 {
-		limitStr := cmd.Args[0]
+		limit, err := strconv.Atoi(cmd.Args[0])
+		if err != nil || limit < 1 {
+			return fmt.Errorf("invalid limit '%s': must be a positive integer", cmd.Args[0])
+		}
+		params.Limit = int32(limit)
+	}
+
+	if limit, ok := cmd.Int("limit"); ok {
+		if limit < 1 {
+			return fmt.Errorf("invalid limit '%d': must be a positive integer", limit)
+		}
+		params.Limit = int32(limit)
+	}
 
-		lim, err := strconv.Atoi(limitStr)
+	if url, ok := cmd.Flag("feed"); ok {
+		feed, err := s.Queries.GetFeedByUrl(context.Background(), url)
 		if err != nil {
-			return fmt.Errorf("invalid limit '%v': %w", lim, err)
+			return fmt.Errorf("failed to get feed by URL '%s': %w", url, err)
 		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
 
-		if lim > limit {
-			limit = lim
+	if value, ok := cmd.Flag("since"); ok {
+		since, err := parseSince(value)
+		if err != nil {
+			return err
 		}
+		params.Since = sql.NullTime{Time: since, Valid: true}
 	}
 
-	rows, err := s.Queries.GetPostsForUser(context.Background(), database.GetPostsForUserParams{
-		ID:    user.ID,
-		Limit: int32(limit),
-	})
+	if value, ok := cmd.Flag("until"); ok {
+		until, err := parseSince(value)
+		if err != nil {
+			return err
+		}
+		params.Until = sql.NullTime{Time: until, Valid: true}
+	}
+
+	if offset, ok := cmd.Int("offset"); ok {
+		if offset < 0 {
+			return fmt.Errorf("invalid offset '%d': must be a non-negative integer", offset)
+		}
+		params.Offset = int32(offset)
+	}
+
+	if page, ok := cmd.Flag("page"); ok {
+		publishedAt, id, err := cursor.Decode(page)
+		if err != nil {
+			return err
+		}
+		params.CursorPublishedAt = sql.NullTime{Time: publishedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	if sort, ok := cmd.Flag("sort"); ok {
+		switch sort {
+		case "newest":
+			params.OldestFirst = false
+		case "oldest":
+			params.OldestFirst = true
+		default:
+			return fmt.Errorf("invalid sort '%s': must be 'newest' or 'oldest'", sort)
+		}
+	}
+
+	rows, err := cursor.Posts(context.Background(), s.Queries, params)
 	if err != nil {
 		return fmt.Errorf("failed to get posts for user '%s': %w", user.Name, err)
 	}
 
+	table := output.Table{
+		Columns: []output.Column{
+			{Key: "id", Label: "ID"},
+			{Key: "title", Label: "Post"},
+			{Key: "url", Label: "URL"},
+			{Key: "user", Label: "User"},
+			{Key: "published_at", Label: "Date"},
+			{Key: "published_at_estimated", Label: "Estimated"},
+			{Key: "read", Label: "Read"},
+			{Key: "starred", Label: "Starred"},
+		},
+		Empty: "no unread posts found, use 'gator browse --all' to list read posts as well",
+	}
+	if params.IncludeRead {
+		table.Empty = "no posts found"
+	}
+
 	for _, row := range rows {
-		line := fmt.Sprintf("---\n")
-		line += fmt.Sprintf("* Post:\t%s\n", row.Post.Title)
-		line += fmt.Sprintf("* URL:\t%s\n", row.Post.Url)
-		line += fmt.Sprintf("* User:\t%s\n", row.User.Name)
-		line += fmt.Sprintf("* Date:\t%s\n", row.Post.PublishedAt.Format(time.RFC1123Z))
-		line += fmt.Sprintf("---\n")
-		fmt.Println(line)
+		table.Append(row.Post.ID.String(), row.Post.Title, row.Post.Url, row.User.Name,
+			row.Post.PublishedAt, row.Post.PublishedAtEstimated, row.Read, row.Starred)
+	}
+
+	if err := output.Render(os.Stdout, cmd.Output, table); err != nil {
+		return err
+	}
+
+	// The hint goes to stderr in the other formats to keep stdout parseable
+	if len(rows) == int(params.Limit) {
+		last := rows[len(rows)-1].Post
+		hint := os.Stdout
+		if cmd.Output != output.Text {
+			hint = os.Stderr
+		}
+		fmt.Fprintf(hint, "more posts with: --page %s\n", cursor.Encode(last.PublishedAt, last.ID))
 	}
 
 	return nil
 }
 
+func Export(s *state.State, cmd Command, user database.User) error {
+	if cmd.Args[0] != "opml" {
+		return fmt.Errorf("unknown export format '%s': only 'opml' is supported", cmd.Args[0])
+	}
+
+	path := ""
+	if len(cmd.Args) > 1 {
+		path = cmd.Args[1]
+	}
+
+	return exportOPML(s, user, path)
+}
+
+// exportOPML writes the feeds followed by the user as OPML to the file at
+// path, or to the standard output when path is empty.
+func exportOPML(s *state.State, user database.User, path string) error {
+	rows, err := s.Queries.GetFeedFollowsByUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get feeds for user '%s': %w", user.Name, err)
+	}
+
+	subscriptions := []opml.Subscription{}
+	for _, row := range rows {
+		subscriptions = append(subscriptions, opml.Subscription{
+			Title:    row.Feed.Name,
+			XMLURL:   row.Feed.Url,
+			HTMLURL:  row.Feed.Link,
+			Category: row.Category,
+		})
+	}
+
+	doc := opml.New(fmt.Sprintf("Feeds followed by %s", user.Name), subscriptions)
+
+	if path == "" {
+		return doc.Write(os.Stdout)
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("failed to create OPML file '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	if err := doc.Write(file); err != nil {
+		return err
+	}
+
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("failed to write OPML file '%s': %w", path, err)
+	}
+
+	fmt.Printf("%d feeds followed by user '%s' were exported to '%s'\n", len(subscriptions), user.Name, path)
+
+	return nil
+}
+
 func Feeds(s *state.State, cmd Command) error {
 	rows, err := s.Queries.GetAllFeedsWithUsers(context.Background())
 	if err != nil {
 		return fmt.Errorf("failed to get feeds: %w", err)
 	}
 
+	table := output.Table{
+		Columns: []output.Column{
+			{Key: "name", Label: "Feed"},
+			{Key: "url", Label: "URL"},
+			{Key: "user", Label: "User"},
+		},
+	}
+
 	for _, row := range rows {
-		line := fmt.Sprintf("---\n")
-		line += fmt.Sprintf("* Feed:\t%s\n", row.Feed.Name)
-		line += fmt.Sprintf("* URL:\t%s\n", row.Feed.Url)
-		line += fmt.Sprintf("* User:\t%s\n", row.User.Name)
-		line += fmt.Sprintf("---\n")
-		fmt.Println(line)
+		table.Append(row.Feed.Name, row.Feed.Url, row.User.Name)
 	}
 
-	return nil
+	return output.Render(os.Stdout, cmd.Output, table)
 }
 
-func Follow(s *state.State, cmd Command) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("usage: gator follow <url>")
+func Feed(s *state.State, cmd Command) error {
+	switch cmd.Args[0] {
+	case "errors":
+		return feedErrors(s, cmd)
+	default:
+		return fmt.Errorf("unknown feed subcommand '%s'", cmd.Args[0])
 	}
+}
 
+func feedErrors(s *state.State, cmd Command) error {
+	feeds, err := s.Queries.GetFailingFeeds(context.Background())
+	if err != nil {
+		return fmt.Errorf("failed to get failing feeds: %w", err)
+	}
+
+	table := output.Table{
+		Columns: []output.Column{
+			{Key: "name", Label: "Feed"},
+			{Key: "url", Label: "URL"},
+			{Key: "consecutive_failures", Label: "Failures"},
+			{Key: "last_error_at", Label: "Date"},
+			{Key: "last_error", Label: "Error"},
+			{Key: "next_fetch_at", Label: "Retry"},
+		},
+		Empty: "no feed is failing",
+	}
+
+	for _, feed := range feeds {
+		table.Append(feed.Name, feed.Url, feed.ConsecutiveFailures, feed.LastErrorAt.Time, feed.LastError, feed.NextFetchAt)
+	}
+
+	return output.Render(os.Stdout, cmd.Output, table)
+}
+
+func Follow(s *state.State, cmd Command) error {
 	url := cmd.Args[0]
 	feed, err := s.Queries.GetFeedByUrl(context.Background(), url)
 	if err != nil {
@@ -194,24 +435,118 @@ func Following(s *state.State, cmd Command) error {
 		return fmt.Errorf("failed to get feeds for user '%s': %w", user.Name, err)
 	}
 
-	if len(rows) == 0 {
-		fmt.Println("you are not following any feeds")
-		return nil
+	table := output.Table{
+		Columns: []output.Column{
+			{Key: "name", Label: "Feed"},
+			{Key: "url", Label: "URL"},
+			{Key: "category", Label: "Category"},
+		},
+		Empty: "you are not following any feeds",
 	}
 
-	fmt.Printf("feeds followed by '%s':\n", user.Name)
 	for _, row := range rows {
-		fmt.Printf(" - %s\n", row.Feed.Name)
+		table.Append(row.Feed.Name, row.Feed.Url, row.Category)
 	}
 
-	return nil
+	return output.Render(os.Stdout, cmd.Output, table)
 }
 
-func Login(s *state.State, cmd Command) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("usage: gator login <username>")
+func Import(s *state.State, cmd Command, user database.User) error {
+	if cmd.Args[0] != "opml" {
+		return fmt.Errorf("unknown import format '%s': only 'opml' is supported", cmd.Args[0])
 	}
 
+	return importOPML(s, user, cmd.Args[1])
+}
+
+// importOPML follows every feed of an OPML file, creating the missing ones,
+// in a single transaction.
+func importOPML(s *state.State, user database.User, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open OPML file '%s': %w", path, err)
+	}
+	defer file.Close()
+
+	doc, err := opml.Parse(file)
+	if err != nil {
+		return fmt.Errorf("failed to parse OPML file '%s': %w", path, err)
+	}
+
+	tx, err := s.Db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	qtx := s.Queries.WithTx(tx)
+
+	follows, err := qtx.GetFeedFollowsByUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get feeds for user '%s': %w", user.Name, err)
+	}
+
+	followed := map[uuid.UUID]bool{}
+	for _, follow := range follows {
+		followed[follow.Feed.ID] = true
+	}
+
+	added, existing, invalid := 0, 0, 0
+
+	for _, sub := range doc.Subscriptions() {
+		feedUrl, err := feeds.ParseURL(sub.XMLURL)
+		if err != nil {
+			fmt.Printf("invalid:  '%s' has no valid feed URL '%s'\n", sub.Title, sub.XMLURL)
+			invalid++
+			continue
+		}
+
+		name := sub.Title
+		if name == "" {
+			name = feedUrl.String()
+		}
+
+		feed, err := qtx.GetFeedByUrl(context.Background(), feedUrl.String())
+		if errors.Is(err, sql.ErrNoRows) {
+			feed, err = qtx.CreateFeed(context.Background(), database.CreateFeedParams{
+				Name:   name,
+				Url:    feedUrl.String(),
+				UserID: user.ID,
+			})
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get or create feed '%s': %w", feedUrl, err)
+		}
+
+		if followed[feed.ID] {
+			fmt.Printf("existing: '%s' (%s)\n", feed.Name, feed.Url)
+			existing++
+			continue
+		}
+
+		if _, err := qtx.CreateFeedFollow(context.Background(), database.CreateFeedFollowParams{
+			FeedID:   feed.ID,
+			UserID:   user.ID,
+			Category: sub.Category,
+		}); err != nil {
+			return fmt.Errorf("failed to follow feed '%s': %w", feed.Url, err)
+		}
+		followed[feed.ID] = true
+
+		fmt.Printf("added:    '%s' (%s)\n", feed.Name, feed.Url)
+		added++
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	fmt.Printf("%d feeds added, %d already followed, %d invalid entries\n", added, existing, invalid)
+
+	return nil
+}
+
+func Login(s *state.State, cmd Command) error {
 	username := cmd.Args[0]
 
 	user, err := s.Queries.GetUser(context.Background(), cmd.Args[0])
@@ -225,11 +560,207 @@ func Login(s *state.State, cmd Command) error {
 	return nil
 }
 
-func Register(s *state.State, cmd Command) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("usage: gator register <username>")
+func Publish(s *state.State, cmd Command) error {
+	username := s.Cfg.CurrentUserName
+	if value, ok := cmd.Flag("user"); ok {
+		username = value
+	}
+
+	format := rss.RSS
+	if value, ok := cmd.Flag("format"); ok {
+		var err error
+		format, err = rss.ParseFormat(value)
+		if err != nil {
+			return err
+		}
+	}
+
+	limit := publish.DefaultLimit
+	if value, ok := cmd.Int("limit"); ok {
+		if value < 1 {
+			return fmt.Errorf("invalid limit '%d': must be a positive integer", value)
+		}
+		limit = value
+	}
+
+	baseURL := "http://localhost:8080"
+	if value, ok := cmd.Flag("base-url"); ok {
+		baseURL = strings.TrimSuffix(value, "/")
+	}
+
+	feed, err := publish.Timeline(context.Background(), s.Queries, username, baseURL, int32(limit))
+	if err != nil {
+		return err
+	}
+
+	return rss.Encode(os.Stdout, feed, format, baseURL+publish.Path(username, format))
+}
+
+func Read(s *state.State, cmd Command, user database.User) error {
+	return setPostRead(s, user, cmd.Args[0], true)
+}
+
+func Unread(s *state.State, cmd Command, user database.User) error {
+	return setPostRead(s, user, cmd.Args[0], false)
+}
+
+func setPostRead(s *state.State, user database.User, ref string, read bool) error {
+	post, err := getPost(s, user, ref)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Queries.SetPostRead(context.Background(), database.SetPostReadParams{
+		UserID: user.ID,
+		PostID: post.ID,
+		Read:   read,
+	}); err != nil {
+		return fmt.Errorf("failed to update post '%s': %w", post.Title, err)
+	}
+
+	status := "read"
+	if !read {
+		status = "unread"
+	}
+	fmt.Printf("post '%s' was marked as %s by user '%s'\n", post.Title, status, user.Name)
+
+	return nil
+}
+
+// getPost finds a post by its ID or, failing that, by its URL among the
+// posts of the feeds followed by user.
+func getPost(s *state.State, user database.User, ref string) (database.Post, error) {
+	if id, err := uuid.Parse(ref); err == nil {
+		post, err := s.Queries.GetPostByID(context.Background(), id)
+		if err != nil {
+			return post, fmt.Errorf("failed to get post by ID '%s': %w", ref, err)
+		}
+		return post, nil
+	}
+
+	post, err := s.Queries.GetPostByUrl(context.Background(), database.GetPostByUrlParams{
+		UserID: user.ID,
+		Url:    ref,
+	})
+	if err != nil {
+		return post, fmt.Errorf("failed to get post by URL '%s': %w", ref, err)
+	}
+
+	return post, nil
+}
+
+func MarkAllRead(s *state.State, cmd Command, user database.User) error {
+	feedID := uuid.NullUUID{}
+	scope := "all followed feeds"
+
+	if len(cmd.Args) > 0 {
+		url := cmd.Args[0]
+		feed, err := s.Queries.GetFeedByUrl(context.Background(), url)
+		if err != nil {
+			return fmt.Errorf("failed to get feed by URL '%s': %w", url, err)
+		}
+
+		feedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+		scope = fmt.Sprintf("feed '%s'", feed.Name)
+	}
+
+	count, err := s.Queries.MarkAllPostsRead(context.Background(), database.MarkAllPostsReadParams{
+		UserID: user.ID,
+		FeedID: feedID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to mark posts as read: %w", err)
+	}
+
+	fmt.Printf("%d posts of %s were marked as read by user '%s'\n", count, scope, user.Name)
+
+	return nil
+}
+
+func Migrate(s *state.State, cmd Command) error {
+	migrator, err := migrate.New(s.Db, schema.FS, migrationTable(s.Cfg))
+	if err != nil {
+		return err
+	}
+
+	ctx := context.Background()
+
+	switch cmd.Args[0] {
+	case "up":
+		migrations, err := migrator.Up(ctx)
+		for _, migration := range migrations {
+			fmt.Printf("applied migration %d_%s\n", migration.Version, migration.Name)
+		}
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			fmt.Printf("database schema is up to date at version %d\n", migrator.Latest())
+		}
+	case "down":
+		migration, ok, err := migrator.Down(ctx)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("no migration to roll back")
+			return nil
+		}
+		fmt.Printf("rolled back migration %d_%s\n", migration.Version, migration.Name)
+	case "status":
+		statuses, err := migrator.Status(ctx)
+		if err != nil {
+			return err
+		}
+
+		table := output.Table{
+			Columns: []output.Column{
+				{Key: "version", Label: "Version"},
+				{Key: "name", Label: "Migration"},
+				{Key: "applied", Label: "Applied"},
+				{Key: "applied_at", Label: "Date"},
+			},
+		}
+
+		for _, status := range statuses {
+			table.Append(status.Version, status.Name, status.Applied, status.AppliedAt)
+		}
+
+		return output.Render(os.Stdout, cmd.Output, table)
+	default:
+		return fmt.Errorf("unknown migrate subcommand '%s'", cmd.Args[0])
+	}
+
+	return nil
+}
+
+// migrationTable returns the goose version table: the migration_table of the
+// config, else the GOOSE_MIGRATION_TABLE environment variable the goose CLI
+// reads, else migrate.DefaultTable.
+func migrationTable(cfg *config.Config) string {
+	if cfg.MigrationTable != "" {
+		return cfg.MigrationTable
+	}
+
+	if table := os.Getenv("GOOSE_MIGRATION_TABLE"); table != "" {
+		return table
+	}
+
+	return migrate.DefaultTable
+}
+
+// CheckSchema fails when the database schema is not at the version of the
+// embedded migrations, which the database package is generated from.
+func CheckSchema(s *state.State) error {
+	migrator, err := migrate.New(s.Db, schema.FS, migrationTable(s.Cfg))
+	if err != nil {
+		return err
 	}
 
+	return migrator.Check(context.Background())
+}
+
+func Register(s *state.State, cmd Command) error {
 	username := cmd.Args[0]
 
 	ctx := context.Background()
@@ -255,11 +786,200 @@ func Reset(s *state.State, cmd Command) error {
 	return nil
 }
 
-func Unfollow(s *state.State, cmd Command, user database.User) error {
-	if len(cmd.Args) < 1 {
-		return fmt.Errorf("usage: gator unfollow <url>")
+func Search(s *state.State, cmd Command, user database.User) error {
+	params := database.SearchPostsForUserParams{
+		Query:  strings.Join(cmd.Args, " "),
+		UserID: user.ID,
+		Limit:  10,
+	}
+
+	if url, ok := cmd.Flag("feed"); ok {
+		feed, err := s.Queries.GetFeedByUrl(context.Background(), url)
+		if err != nil {
+			return fmt.Errorf("failed to get feed by URL '%s': %w", url, err)
+		}
+		params.FeedID = uuid.NullUUID{UUID: feed.ID, Valid: true}
+	}
+
+	if value, ok := cmd.Flag("since"); ok {
+		since, err := parseSince(value)
+		if err != nil {
+			return err
+		}
+		params.Since = sql.NullTime{Time: since, Valid: true}
+	}
+
+	if limit, ok := cmd.Int("limit"); ok {
+		if limit < 1 {
+			return fmt.Errorf("invalid limit '%d': must be a positive integer", limit)
+		}
+		params.Limit = int32(limit)
+	}
+
+	rows, err := s.Queries.SearchPostsForUser(context.Background(), params)
+	if err != nil {
+		return fmt.Errorf("failed to search posts for user '%s': %w", user.Name, err)
+	}
+
+	table := output.Table{
+		Columns: []output.Column{
+			{Key: "id", Label: "ID"},
+			{Key: "title", Label: "Post"},
+			{Key: "url", Label: "URL"},
+			{Key: "feed", Label: "Feed"},
+			{Key: "published_at", Label: "Date"},
+			{Key: "snippet", Label: "Match"},
+		},
+		Empty: fmt.Sprintf("no post matches '%s'", params.Query),
 	}
 
+	// Matches are highlighted in bold in text, and left as plain text in the
+	// other formats
+	highlighter := strings.NewReplacer("<mark>", "", "</mark>", "")
+	if cmd.Output == output.Text {
+		highlighter = strings.NewReplacer("<mark>", "\033[1m", "</mark>", "\033[0m")
+	}
+
+	for _, row := range rows {
+		table.Append(row.Post.ID.String(), row.Post.Title, row.Post.Url, row.FeedName,
+			row.Post.PublishedAt, highlighter.Replace(row.Snippet))
+	}
+
+	return output.Render(os.Stdout, cmd.Output, table)
+}
+
+// parseSince parses either a date or a duration to go back from now.
+func parseSince(value string) (time.Time, error) {
+	if duration, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-duration).UTC(), nil
+	}
+
+	since, err := rss.ParseDate(value)
+	if err != nil {
+		return since, fmt.Errorf("invalid date or duration '%s': %w", value, err)
+	}
+
+	return since.UTC(), nil
+}
+
+// serveShutdownTimeout is how long serve waits for in-flight requests on
+// shutdown.
+const serveShutdownTimeout = 10 * time.Second
+
+func Serve(s *state.State, cmd Command) error {
+	addr := "localhost:8080"
+	if value, ok := cmd.Flag("addr"); ok {
+		addr = value
+	}
+
+	store := api.NewDBStore(s.Db)
+
+	reader, err := web.NewServer(store, s.Cfg.CurrentUserName)
+	if err != nil {
+		return err
+	}
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/", api.NewServer(store))
+	mux.Handle("/publish/", publish.Handler(store))
+	greaderServer := greader.NewServer(store)
+	mux.Handle("/accounts/", greaderServer)
+	mux.Handle("/reader/", greaderServer)
+	feverServer := fever.NewServer(store)
+	mux.Handle("/fever", feverServer)
+	mux.Handle("/fever/", feverServer)
+	mux.Handle("/", reader)
+
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           api.LogRequests(mux),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errs := make(chan error, 1)
+	go func() {
+		errs <- server.ListenAndServe()
+	}()
+
+	fmt.Printf("Serving on http://%s\n", addr)
+
+	select {
+	case err := <-errs:
+		return fmt.Errorf("failed to serve on '%s': %w", addr, err)
+	case <-ctx.Done():
+	}
+
+	fmt.Println("Shutting down")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), serveShutdownTimeout)
+	defer cancel()
+
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		return fmt.Errorf("failed to shut down: %w", err)
+	}
+
+	return nil
+}
+
+func Star(s *state.State, cmd Command, user database.User) error {
+	return setPostStarred(s, user, cmd.Args[0], true)
+}
+
+func Unstar(s *state.State, cmd Command, user database.User) error {
+	return setPostStarred(s, user, cmd.Args[0], false)
+}
+
+func setPostStarred(s *state.State, user database.User, ref string, starred bool) error {
+	post, err := getPost(s, user, ref)
+	if err != nil {
+		return err
+	}
+
+	if err := s.Queries.SetPostStarred(context.Background(), database.SetPostStarredParams{
+		UserID:  user.ID,
+		PostID:  post.ID,
+		Starred: starred,
+	}); err != nil {
+		return fmt.Errorf("failed to update post '%s': %w", post.Title, err)
+	}
+
+	if starred {
+		fmt.Printf("post '%s' was starred by user '%s'\n", post.Title, user.Name)
+	} else {
+		fmt.Printf("post '%s' was unstarred by user '%s'\n", post.Title, user.Name)
+	}
+
+	return nil
+}
+
+func Starred(s *state.State, cmd Command, user database.User) error {
+	rows, err := s.Queries.GetStarredPostsForUser(context.Background(), user.ID)
+	if err != nil {
+		return fmt.Errorf("failed to get starred posts for user '%s': %w", user.Name, err)
+	}
+
+	table := output.Table{
+		Columns: []output.Column{
+			{Key: "id", Label: "ID"},
+			{Key: "title", Label: "Post"},
+			{Key: "url", Label: "URL"},
+			{Key: "published_at", Label: "Date"},
+			{Key: "starred_at", Label: "Starred"},
+		},
+		Empty: "you have not starred any post",
+	}
+
+	for _, row := range rows {
+		table.Append(row.Post.ID.String(), row.Post.Title, row.Post.Url, row.Post.PublishedAt, row.StarredAt.Time)
+	}
+
+	return output.Render(os.Stdout, cmd.Output, table)
+}
+
+func Unfollow(s *state.State, cmd Command, user database.User) error {
 	url := cmd.Args[0]
 	feed, err := s.Queries.GetFeedByUrl(context.Background(), url)
 	if err != nil {
@@ -284,15 +1004,16 @@ func Users(s *state.State, cmd Command) error {
 		return fmt.Errorf("failed to get users: %w", err)
 	}
 
-	for _, user := range users {
-		line := fmt.Sprintf("* %s", user.Name)
-
-		if user.Name == s.Cfg.CurrentUserName {
-			line += " (current)"
-		}
+	table := output.Table{
+		Columns: []output.Column{
+			{Key: "name", Label: "User"},
+			{Key: "current", Label: "Current"},
+		},
+	}
 
-		fmt.Println(line)
+	for _, user := range users {
+		table.Append(user.Name, user.Name == s.Cfg.CurrentUserName)
 	}
 
-	return nil
+	return output.Render(os.Stdout, cmd.Output, table)
 }
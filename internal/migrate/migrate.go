@@ -0,0 +1,401 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultTable is the goose version table of the .env of the repository,
+// used when none is configured. A database migrated with the goose CLI and
+// one migrated by gator agree as long as they use the same table.
+const DefaultTable = "custom.goose_migrations"
+
+// tableName matches a table name, optionally qualified by its schema, that
+// is safe to use unquoted in the queries on the version table.
+var tableName = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// Migration is a goose SQL migration. The version is the timestamp prefix
+// of its file name. NoTransaction is set by the '-- +goose NO TRANSACTION'
+// annotation, for statements like CREATE INDEX CONCURRENTLY.
+type Migration struct {
+	Version       int64
+	Name          string
+	Up            []string
+	Down          []string
+	NoTransaction bool
+}
+
+// Status is a migration with whether it is applied to the database.
+type Status struct {
+	Migration
+	Applied   bool
+	AppliedAt time.Time
+}
+
+type Migrator struct {
+	db         *sql.DB
+	table      string
+	migrations []Migration
+}
+
+// New loads the migrations of fsys, sorted by version, whose versions are
+// recorded in table.
+func New(db *sql.DB, fsys fs.FS, table string) (*Migrator, error) {
+	if !tableName.MatchString(table) {
+		return nil, fmt.Errorf("invalid migration table '%s': must be a table name, optionally qualified by its schema", table)
+	}
+
+	paths, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	migrations := []Migration{}
+	for _, p := range paths {
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read migration '%s': %w", p, err)
+		}
+
+		migration, err := parse(p, string(data))
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return &Migrator{db: db, table: table, migrations: migrations}, nil
+}
+
+// parse splits a goose migration into the statements of its Up and Down
+// sections. Like goose, a statement ends with a line ending with a
+// semicolon, unless it is between the StatementBegin and StatementEnd
+// annotations.
+func parse(p string, data string) (Migration, error) {
+	base := strings.TrimSuffix(path.Base(p), ".sql")
+
+	versionStr, name, ok := strings.Cut(base, "_")
+	if !ok {
+		return Migration{}, fmt.Errorf("invalid migration file name '%s': must be <version>_<name>.sql", p)
+	}
+
+	version, err := strconv.ParseInt(versionStr, 10, 64)
+	if err != nil {
+		return Migration{}, fmt.Errorf("invalid migration version '%s': %w", versionStr, err)
+	}
+
+	migration := Migration{Version: version, Name: name}
+
+	var section *[]string
+	statement := ""
+	inBlock := false
+
+	// flush ends the current statement, skipping the ones made only of
+	// comments and blank lines
+	flush := func() {
+		if section != nil && !isBlank(statement) {
+			*section = append(*section, strings.TrimSpace(statement))
+		}
+		statement = ""
+	}
+
+	// end checks that the last statement of a section is terminated
+	end := func() error {
+		if inBlock || !isBlank(statement) {
+			return fmt.Errorf("migration '%s' has an unterminated statement: %s", p, strings.TrimSpace(statement))
+		}
+		statement = ""
+		return nil
+	}
+
+	for _, line := range strings.SplitAfter(data, "\n") {
+		switch strings.TrimSpace(line) {
+		case "-- +goose Up":
+			if err := end(); err != nil {
+				return Migration{}, err
+			}
+			section = &migration.Up
+			continue
+		case "-- +goose Down":
+			if err := end(); err != nil {
+				return Migration{}, err
+			}
+			section = &migration.Down
+			continue
+		case "-- +goose NO TRANSACTION":
+			migration.NoTransaction = true
+			continue
+		case "-- +goose StatementBegin":
+			inBlock = true
+			continue
+		case "-- +goose StatementEnd":
+			inBlock = false
+			flush()
+			continue
+		}
+
+		if section == nil {
+			continue
+		}
+
+		statement += line
+		if !inBlock && strings.HasSuffix(strings.TrimSpace(line), ";") {
+			flush()
+		}
+	}
+
+	if err := end(); err != nil {
+		return Migration{}, err
+	}
+
+	if len(migration.Up) == 0 {
+		return Migration{}, fmt.Errorf("migration '%s' has no '-- +goose Up' section", p)
+	}
+
+	return migration, nil
+}
+
+// isBlank reports whether sql has only comments and blank lines.
+func isBlank(sql string) bool {
+	for _, line := range strings.Split(sql, "\n") {
+		line = strings.TrimSpace(line)
+		if line != "" && !strings.HasPrefix(line, "--") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Latest returns the version of the last migration, which the generated
+// database package is built against.
+func (m *Migrator) Latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// applied returns the applied versions with the time they were applied at.
+// A database without the version table has none.
+func (m *Migrator) applied(ctx context.Context) (map[int64]time.Time, error) {
+	applied := map[int64]time.Time{}
+
+	var exists bool
+	if err := m.db.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL", m.table).Scan(&exists); err != nil {
+		return applied, fmt.Errorf("failed to look up version table: %w", err)
+	}
+	if !exists {
+		return applied, nil
+	}
+
+	rows, err := m.db.QueryContext(ctx, "SELECT version_id, tstamp FROM "+m.table+" WHERE is_applied AND version_id > 0")
+	if err != nil {
+		return applied, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var version int64
+		var appliedAt time.Time
+		if err := rows.Scan(&version, &appliedAt); err != nil {
+			return applied, fmt.Errorf("failed to scan applied migration: %w", err)
+		}
+		applied[version] = appliedAt
+	}
+
+	if err := rows.Err(); err != nil {
+		return applied, fmt.Errorf("failed to get applied migrations: %w", err)
+	}
+
+	return applied, nil
+}
+
+// Version returns the version of the last applied migration, or 0.
+func (m *Migrator) Version(ctx context.Context) (int64, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	version := int64(0)
+	for v := range applied {
+		version = max(version, v)
+	}
+
+	return version, nil
+}
+
+func (m *Migrator) Status(ctx context.Context) ([]Status, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	statuses := []Status{}
+	for _, migration := range m.migrations {
+		appliedAt, ok := applied[migration.Version]
+		statuses = append(statuses, Status{Migration: migration, Applied: ok, AppliedAt: appliedAt})
+	}
+
+	return statuses, nil
+}
+
+// Check returns an error when the database schema is not at the latest
+// version.
+func (m *Migrator) Check(ctx context.Context) error {
+	version, err := m.Version(ctx)
+	if err != nil {
+		return err
+	}
+
+	return check(version, m.Latest())
+}
+
+// check compares the version of the database schema with the latest one.
+func check(version int64, latest int64) error {
+	switch {
+	case version < latest:
+		return fmt.Errorf("database schema is at version %d but gator expects %d, run 'gator migrate up'", version, latest)
+	case version > latest:
+		return fmt.Errorf("database schema is at version %d, newer than the version %d gator expects, upgrade gator", version, latest)
+	default:
+		return nil
+	}
+}
+
+// ensureTable creates the version table, and its schema, the way goose does.
+func (m *Migrator) ensureTable(ctx context.Context) error {
+	if schema, _, ok := strings.Cut(m.table, "."); ok {
+		if _, err := m.db.ExecContext(ctx, "CREATE SCHEMA IF NOT EXISTS "+schema); err != nil {
+			return fmt.Errorf("failed to create version table schema: %w", err)
+		}
+	}
+
+	_, err := m.db.ExecContext(ctx, fmt.Sprintf(`
+CREATE TABLE IF NOT EXISTS %s (
+    id INTEGER PRIMARY KEY GENERATED BY DEFAULT AS IDENTITY,
+    version_id BIGINT NOT NULL,
+    is_applied BOOLEAN NOT NULL,
+    tstamp TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+INSERT INTO %s (version_id, is_applied)
+SELECT 0, TRUE
+WHERE NOT EXISTS (SELECT 1 FROM %s);`, m.table, m.table, m.table))
+	if err != nil {
+		return fmt.Errorf("failed to create version table: %w", err)
+	}
+
+	return nil
+}
+
+// Up applies the pending migrations in order, each in its own transaction
+// unless it is annotated with NO TRANSACTION, and returns the applied ones.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	done := []Migration{}
+
+	if err := m.ensureTable(ctx); err != nil {
+		return done, err
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return done, err
+	}
+
+	for _, migration := range m.migrations {
+		if _, ok := applied[migration.Version]; ok {
+			continue
+		}
+
+		err := m.run(ctx, migration, migration.Up, "INSERT INTO "+m.table+" (version_id, is_applied) VALUES ($1, TRUE)")
+		if err != nil {
+			return done, fmt.Errorf("failed to apply migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		done = append(done, migration)
+	}
+
+	return done, nil
+}
+
+// Down rolls back the last applied migration and returns it. It returns
+// false when no migration is applied.
+func (m *Migrator) Down(ctx context.Context) (Migration, bool, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return Migration{}, false, err
+	}
+
+	for i := len(m.migrations) - 1; i >= 0; i-- {
+		migration := m.migrations[i]
+		if _, ok := applied[migration.Version]; !ok {
+			continue
+		}
+
+		err := m.run(ctx, migration, migration.Down, "DELETE FROM "+m.table+" WHERE version_id = $1")
+		if err != nil {
+			return migration, false, fmt.Errorf("failed to roll back migration %d_%s: %w", migration.Version, migration.Name, err)
+		}
+
+		return migration, true, nil
+	}
+
+	return Migration{}, false, nil
+}
+
+// run executes the statements of a migration section and records it in the
+// version table, in a single transaction unless the migration is annotated
+// with NO TRANSACTION.
+func (m *Migrator) run(ctx context.Context, migration Migration, statements []string, record string) error {
+	if migration.NoTransaction {
+		for _, statement := range statements {
+			if _, err := m.db.ExecContext(ctx, statement); err != nil {
+				return err
+			}
+		}
+
+		if _, err := m.db.ExecContext(ctx, record, migration.Version); err != nil {
+			return fmt.Errorf("failed to record version: %w", err)
+		}
+
+		return nil
+	}
+
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, statement := range statements {
+		if _, err := tx.ExecContext(ctx, statement); err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, record, migration.Version); err != nil {
+		return fmt.Errorf("failed to record version: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
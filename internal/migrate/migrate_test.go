@@ -0,0 +1,158 @@
+package migrate
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestParse(t *testing.T) {
+	data := `-- +goose Up
+-- A comment before the first statement
+CREATE TABLE feeds (
+    id UUID PRIMARY KEY
+);
+
+ALTER TABLE feeds
+ADD COLUMN name TEXT;
+
+-- +goose StatementBegin
+CREATE FUNCTION touch() RETURNS TRIGGER AS $$
+BEGIN
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose Down
+DROP FUNCTION touch;
+
+DROP TABLE feeds;
+-- A trailing comment
+`
+
+	migration, err := parse("sql/schema/20250609185009_feeds.sql", data)
+	if err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+
+	want := Migration{
+		Version: 20250609185009,
+		Name:    "feeds",
+		Up: []string{
+			"-- A comment before the first statement\nCREATE TABLE feeds (\n    id UUID PRIMARY KEY\n);",
+			"ALTER TABLE feeds\nADD COLUMN name TEXT;",
+			"CREATE FUNCTION touch() RETURNS TRIGGER AS $$\nBEGIN\n    RETURN NEW;\nEND;\n$$ LANGUAGE plpgsql;",
+		},
+		Down: []string{
+			"DROP FUNCTION touch;",
+			"DROP TABLE feeds;",
+		},
+	}
+	if !reflect.DeepEqual(migration, want) {
+		t.Errorf("parse() = %#v, want %#v", migration, want)
+	}
+}
+
+func TestParseNoTransaction(t *testing.T) {
+	data := `-- +goose NO TRANSACTION
+-- +goose Up
+CREATE INDEX CONCURRENTLY posts_url_idx ON posts (url);
+
+-- +goose Down
+DROP INDEX CONCURRENTLY posts_url_idx;
+`
+
+	migration, err := parse("20261019000000_posts_url_index.sql", data)
+	if err != nil {
+		t.Fatalf("parse() error = %v", err)
+	}
+
+	if !migration.NoTransaction {
+		t.Error("parse() NoTransaction = false, want true")
+	}
+	if len(migration.Up) != 1 || len(migration.Down) != 1 {
+		t.Errorf("parse() = %d up and %d down statements, want 1 and 1", len(migration.Up), len(migration.Down))
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		path string
+		data string
+		want string
+	}{
+		{"no version", "feeds.sql", "-- +goose Up\nSELECT 1;\n", "invalid migration file name"},
+		{"invalid version", "v1_feeds.sql", "-- +goose Up\nSELECT 1;\n", "invalid migration version"},
+		{"no up section", "1_feeds.sql", "SELECT 1;\n", "no '-- +goose Up' section"},
+		{"empty up section", "1_feeds.sql", "-- +goose Up\n-- +goose Down\nSELECT 1;\n", "no '-- +goose Up' section"},
+		{"unterminated statement", "1_feeds.sql", "-- +goose Up\nSELECT 1\n-- +goose Down\nSELECT 2;\n", "unterminated statement"},
+		{"unterminated block", "1_feeds.sql", "-- +goose Up\n-- +goose StatementBegin\nSELECT 1;\n", "unterminated statement"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parse(tt.path, tt.data)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("parse() error = %v, want an error containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNew(t *testing.T) {
+	fsys := fstest.MapFS{
+		"2_posts.sql": {Data: []byte("-- +goose Up\nSELECT 2;\n")},
+		"1_feeds.sql": {Data: []byte("-- +goose Up\nSELECT 1;\n")},
+		"schema.go":   {Data: []byte("package schema\n")},
+	}
+
+	migrator, err := New(nil, fsys, "goose_db_version")
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+
+	if len(migrator.migrations) != 2 || migrator.migrations[0].Name != "feeds" || migrator.migrations[1].Name != "posts" {
+		t.Errorf("New() migrations = %v, want feeds then posts", migrator.migrations)
+	}
+	if migrator.Latest() != 2 {
+		t.Errorf("Latest() = %d, want 2", migrator.Latest())
+	}
+
+	for _, table := range []string{"", "goose migrations", "custom.goose;DROP TABLE posts", "a.b.c"} {
+		if _, err := New(nil, fsys, table); err == nil {
+			t.Errorf("New() with table %q succeeded, want an error", table)
+		}
+	}
+}
+
+func TestCheck(t *testing.T) {
+	tests := []struct {
+		name    string
+		version int64
+		latest  int64
+		want    string
+	}{
+		{"up to date", 3, 3, ""},
+		{"empty database", 0, 3, "run 'gator migrate up'"},
+		{"behind", 2, 3, "run 'gator migrate up'"},
+		{"ahead", 4, 3, "upgrade gator"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := check(tt.version, tt.latest)
+			if tt.want == "" {
+				if err != nil {
+					t.Errorf("check(%d, %d) error = %v, want nil", tt.version, tt.latest, err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("check(%d, %d) error = %v, want an error containing %q", tt.version, tt.latest, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package opml
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// OPML is an OPML 1.0 or 2.0 subscription list.
+type OPML struct {
+	XMLName xml.Name `xml:"opml"`
+	Version string   `xml:"version,attr"`
+	Head    struct {
+		Title       string `xml:"title"`
+		DateCreated string `xml:"dateCreated,omitempty"`
+	} `xml:"head"`
+	Body struct {
+		Outlines []Outline `xml:"outline"`
+	} `xml:"body"`
+}
+
+// Outline is either a subscription, when XMLURL is set, or a folder of
+// nested outlines.
+type Outline struct {
+	Text     string    `xml:"text,attr"`
+	Title    string    `xml:"title,attr,omitempty"`
+	Type     string    `xml:"type,attr,omitempty"`
+	XMLURL   string    `xml:"xmlUrl,attr,omitempty"`
+	HTMLURL  string    `xml:"htmlUrl,attr,omitempty"`
+	Category string    `xml:"category,attr,omitempty"`
+	Outlines []Outline `xml:"outline"`
+}
+
+// Subscription is a feed of an OPML document with the folders it is nested
+// in flattened into a category.
+type Subscription struct {
+	Title    string
+	XMLURL   string
+	HTMLURL  string
+	Category string
+}
+
+// New builds an OPML 2.0 document from subscriptions, nesting them in
+// folders according to their category.
+func New(title string, subscriptions []Subscription) *OPML {
+	doc := &OPML{Version: "2.0"}
+	doc.Head.Title = title
+	doc.Head.DateCreated = time.Now().UTC().Format(time.RFC1123)
+
+	for _, sub := range subscriptions {
+		outline := Outline{
+			Text:    sub.Title,
+			Title:   sub.Title,
+			Type:    "rss",
+			XMLURL:  sub.XMLURL,
+			HTMLURL: sub.HTMLURL,
+		}
+
+		outlines := &doc.Body.Outlines
+		for _, folder := range strings.Split(sub.Category, "/") {
+			if folder == "" {
+				continue
+			}
+			outlines = &folderOutline(outlines, folder).Outlines
+		}
+
+		*outlines = append(*outlines, outline)
+	}
+
+	return doc
+}
+
+// folderOutline returns the folder with the given name, adding it to
+// outlines when it does not exist yet.
+func folderOutline(outlines *[]Outline, name string) *Outline {
+	for i := range *outlines {
+		if (*outlines)[i].XMLURL == "" && (*outlines)[i].Text == name {
+			return &(*outlines)[i]
+		}
+	}
+
+	*outlines = append(*outlines, Outline{Text: name, Title: name})
+
+	return &(*outlines)[len(*outlines)-1]
+}
+
+// Write encodes the document as indented XML.
+func (o *OPML) Write(w io.Writer) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return fmt.Errorf("failed to write OPML header: %w", err)
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(o); err != nil {
+		return fmt.Errorf("failed to marshal OPML: %w", err)
+	}
+
+	if _, err := io.WriteString(w, "\n"); err != nil {
+		return fmt.Errorf("failed to write OPML: %w", err)
+	}
+
+	return nil
+}
+
+func Parse(r io.Reader) (*OPML, error) {
+	doc := &OPML{}
+
+	if err := xml.NewDecoder(r).Decode(doc); err != nil {
+		return doc, fmt.Errorf("failed to unmarshal OPML: %w", err)
+	}
+
+	return doc, nil
+}
+
+// Subscriptions lists every subscription of the document, depth first.
+// Nested folders are joined with "/" into the category.
+func (o *OPML) Subscriptions() []Subscription {
+	subscriptions := []Subscription{}
+	for _, outline := range o.Body.Outlines {
+		subscriptions = outline.collect(nil, subscriptions)
+	}
+
+	return subscriptions
+}
+
+func (o Outline) collect(folders []string, subscriptions []Subscription) []Subscription {
+	title := strings.TrimSpace(o.Title)
+	if title == "" {
+		title = strings.TrimSpace(o.Text)
+	}
+
+	if o.XMLURL == "" && len(o.Outlines) > 0 {
+		folders = append(folders[:len(folders):len(folders)], title)
+		for _, child := range o.Outlines {
+			subscriptions = child.collect(folders, subscriptions)
+		}
+		return subscriptions
+	}
+
+	category := strings.Join(folders, "/")
+	if category == "" {
+		// OPML 2.0 categories are comma-separated slash-delimited paths
+		first, _, _ := strings.Cut(o.Category, ",")
+		category = strings.Trim(strings.TrimSpace(first), "/")
+	}
+
+	return append(subscriptions, Subscription{
+		Title:    title,
+		XMLURL:   strings.TrimSpace(o.XMLURL),
+		HTMLURL:  strings.TrimSpace(o.HTMLURL),
+		Category: category,
+	})
+}
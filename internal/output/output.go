@@ -0,0 +1,164 @@
+package output
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+)
+
+type Format string
+
+const (
+	Text Format = "text"
+	JSON Format = "json"
+	CSV  Format = "csv"
+	TSV  Format = "tsv"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(s); format {
+	case Text, JSON, CSV, TSV:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown output format '%s': must be one of text, json, csv or tsv", s)
+	}
+}
+
+// Column describes a field of a Table. Key names it in the JSON, CSV and TSV
+// formats and Label in the text format.
+type Column struct {
+	Key   string
+	Label string
+}
+
+// Table holds the structured rows of a listing command so that they can be
+// rendered in any Format. Values are strings, booleans, numbers or times.
+type Table struct {
+	Columns []Column
+	Rows    [][]any
+	// Empty is printed instead of the rows in the text format when there
+	// are none.
+	Empty string
+}
+
+func (t *Table) Append(values ...any) {
+	t.Rows = append(t.Rows, values)
+}
+
+func Render(w io.Writer, format Format, table Table) error {
+	switch format {
+	case JSON:
+		return renderJSON(w, table)
+	case CSV:
+		return renderDelimited(w, table, ',')
+	case TSV:
+		return renderDelimited(w, table, '\t')
+	default:
+		return renderText(w, table)
+	}
+}
+
+// renderText prints each row as a block of labelled lines, leaving out the
+// empty values.
+func renderText(w io.Writer, table Table) error {
+	if len(table.Rows) == 0 && table.Empty != "" {
+		_, err := fmt.Fprintln(w, table.Empty)
+		return err
+	}
+
+	for _, row := range table.Rows {
+		block := fmt.Sprintf("---\n")
+		for i, value := range row {
+			text := formatValue(value, true)
+			if text == "" {
+				continue
+			}
+			block += fmt.Sprintf("* %s:\t%s\n", table.Columns[i].Label, text)
+		}
+		block += fmt.Sprintf("---\n")
+
+		if _, err := fmt.Fprintln(w, block); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func renderJSON(w io.Writer, table Table) error {
+	objects := make([]map[string]any, 0, len(table.Rows))
+	for _, row := range table.Rows {
+		object := map[string]any{}
+		for i, value := range row {
+			object[table.Columns[i].Key] = value
+		}
+		objects = append(objects, object)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(objects); err != nil {
+		return fmt.Errorf("failed to encode JSON: %w", err)
+	}
+
+	return nil
+}
+
+func renderDelimited(w io.Writer, table Table, delimiter rune) error {
+	writer := csv.NewWriter(w)
+	writer.Comma = delimiter
+
+	header := []string{}
+	for _, column := range table.Columns {
+		header = append(header, column.Key)
+	}
+	if err := writer.Write(header); err != nil {
+		return fmt.Errorf("failed to write header: %w", err)
+	}
+
+	for _, row := range table.Rows {
+		record := []string{}
+		for _, value := range row {
+			record = append(record, formatValue(value, false))
+		}
+		if err := writer.Write(record); err != nil {
+			return fmt.Errorf("failed to write row: %w", err)
+		}
+	}
+
+	writer.Flush()
+
+	return writer.Error()
+}
+
+// formatValue converts a cell to a string. In the text format, false
+// booleans and zero times are empty so that they can be left out.
+func formatValue(value any, text bool) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case bool:
+		if !text {
+			return strconv.FormatBool(v)
+		}
+		if v {
+			return "yes"
+		}
+		return ""
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		if text {
+			return v.Format(time.RFC1123Z)
+		}
+		return v.Format(time.RFC3339)
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(v)
+	}
+}
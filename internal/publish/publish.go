@@ -0,0 +1,120 @@
+// Package publish re-publishes the timeline of a user, the posts of all the
+// feeds they follow, as a single feed.
+package publish
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"gator/internal/cursor"
+	"gator/internal/database"
+	"gator/internal/rss"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	DefaultLimit = 50
+	maxLimit     = 200
+)
+
+// Path returns the path the timeline of a user is served at.
+func Path(username string, format rss.Format) string {
+	return fmt.Sprintf("/publish/%s/%s", url.PathEscape(username), format)
+}
+
+// Timeline builds the feed of the latest posts, read or not, of the feeds
+// followed by a user. Its link points to the web reader at baseURL.
+func Timeline(ctx context.Context, q database.Querier, username string, baseURL string, limit int32) (*rss.Feed, error) {
+	user, err := q.GetUser(ctx, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user '%s': %w", username, err)
+	}
+
+	rows, err := cursor.Posts(ctx, q, cursor.PostsParams{
+		ID:          user.ID,
+		IncludeRead: true,
+		Limit:       limit,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get posts for user '%s': %w", user.Name, err)
+	}
+
+	feed := &rss.Feed{
+		Title:       fmt.Sprintf("Feeds followed by %s", user.Name),
+		Link:        strings.TrimSuffix(baseURL, "/") + "/",
+		Description: fmt.Sprintf("The posts of all the feeds followed by %s on gator", user.Name),
+	}
+
+	for _, row := range rows {
+		feed.Items = append(feed.Items, rss.Item{
+			// The posts of different feeds may share a GUID, so the ID of the
+			// post identifies it in the merged feed
+			GUID:        "urn:uuid:" + row.Post.ID.String(),
+			Title:       row.Post.Title,
+			Link:        row.Post.Url,
+			Description: row.Post.Description,
+			Author:      row.Post.Author,
+			PublishedAt: row.Post.PublishedAt,
+		})
+	}
+
+	return feed, nil
+}
+
+// Handler serves the timelines at /publish/{user}/{format}, where format is
+// rss, atom or json, with an optional limit query parameter.
+func Handler(q database.Querier) http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /publish/{user}/{format}", func(w http.ResponseWriter, r *http.Request) {
+		format, err := rss.ParseFormat(r.PathValue("format"))
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+
+		limit := DefaultLimit
+		if value := r.URL.Query().Get("limit"); value != "" {
+			limit, err = strconv.Atoi(value)
+			if err != nil || limit < 1 || limit > maxLimit {
+				http.Error(w, fmt.Sprintf("invalid limit '%s': must be an integer between 1 and %d", value, maxLimit), http.StatusBadRequest)
+				return
+			}
+		}
+
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		baseURL := scheme + "://" + r.Host
+
+		feed, err := Timeline(r.Context(), q, r.PathValue("user"), baseURL, int32(limit))
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "no such user", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("error handling %s %s: %v", r.Method, r.URL.Path, err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		buf := &bytes.Buffer{}
+		if err := rss.Encode(buf, feed, format, baseURL+r.URL.RequestURI()); err != nil {
+			log.Printf("error handling %s %s: %v", r.Method, r.URL.Path, err)
+			http.Error(w, "internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		w.Header().Set("Content-Type", format.ContentType())
+		buf.WriteTo(w)
+	})
+
+	return mux
+}
@@ -0,0 +1,132 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+)
+
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+type AtomFeed struct {
+	Title    AtomContent `xml:"title"`
+	Subtitle string      `xml:"subtitle"`
+	Links    []AtomLink  `xml:"link"`
+	Entries  []AtomEntry `xml:"entry"`
+}
+
+type AtomEntry struct {
+	ID        string       `xml:"id"`
+	Title     AtomContent  `xml:"title"`
+	Links     []AtomLink   `xml:"link"`
+	Summary   AtomContent  `xml:"summary"`
+	Content   AtomContent  `xml:"content"`
+	Updated   string       `xml:"updated"`
+	Published string       `xml:"published"`
+	Authors   []AtomPerson `xml:"author"`
+}
+
+type AtomPerson struct {
+	Name string `xml:"name"`
+}
+
+// AtomContent is a text construct, whose type is text, html or xhtml.
+type AtomContent struct {
+	Type  string `xml:"type,attr"`
+	Text  string `xml:",chardata"`
+	Inner string `xml:",innerxml"`
+}
+
+// String returns the content as text or HTML. The markup of xhtml content is
+// kept as is, without the div that wraps it.
+func (c AtomContent) String() string {
+	if c.Type != "xhtml" {
+		return c.Text
+	}
+
+	inner := strings.TrimSpace(c.Inner)
+	if strings.HasPrefix(inner, "<div") && strings.HasSuffix(inner, "</div>") {
+		if end := strings.Index(inner, ">"); end >= 0 {
+			inner = strings.TrimSpace(inner[end+1 : len(inner)-len("</div>")])
+		}
+	}
+
+	return inner
+}
+
+// Plain returns the content as plain text, for titles. The markup of html
+// and xhtml content is stripped and its entities are unescaped.
+func (c AtomContent) Plain() string {
+	if c.Type != "html" && c.Type != "xhtml" {
+		return strings.TrimSpace(c.Text)
+	}
+
+	text := html.UnescapeString(tags.ReplaceAllString(c.String(), ""))
+	return strings.Join(strings.Fields(text), " ")
+}
+
+type AtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+}
+
+func parseAtom(data []byte) (*Feed, error) {
+	feed := &Feed{}
+
+	atomFeed := &AtomFeed{}
+	if err := xml.Unmarshal(data, atomFeed); err != nil {
+		return feed, fmt.Errorf("failed to unmarshal Atom XML: %w", err)
+	}
+
+	feed.Title = atomFeed.Title.Plain()
+	feed.Link = alternateLink(atomFeed.Links)
+	feed.Description = atomFeed.Subtitle
+
+	for _, entry := range atomFeed.Entries {
+		description := entry.Summary.String()
+		if description == "" {
+			description = entry.Content.String()
+		}
+
+		pubDate := entry.Published
+		if pubDate == "" {
+			pubDate = entry.Updated
+		}
+
+		names := []string{}
+		for _, author := range entry.Authors {
+			if author.Name != "" {
+				names = append(names, author.Name)
+			}
+		}
+
+		feed.Items = append(feed.Items, Item{
+			GUID:        entry.ID,
+			Title:       entry.Title.Plain(),
+			Link:        alternateLink(entry.Links),
+			Description: description,
+			PubDate:     pubDate,
+			Author:      strings.Join(names, ", "),
+		})
+	}
+
+	return feed, nil
+}
+
+// alternateLink returns the href of the "alternate" link, which is the
+// default relation when none is given. It falls back to the first link.
+func alternateLink(links []AtomLink) string {
+	for _, link := range links {
+		if link.Rel == "" || link.Rel == "alternate" {
+			return link.Href
+		}
+	}
+
+	if len(links) > 0 {
+		return links[0].Href
+	}
+
+	return ""
+}
@@ -0,0 +1,77 @@
+package rss
+
+import "testing"
+
+func TestParseAtomContent(t *testing.T) {
+	data := []byte(`<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title>Example</title>
+  <entry>
+    <id>xhtml</id>
+    <content type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml"><p>Hello <b>world</b></p></div></content>
+  </entry>
+  <entry>
+    <id>html</id>
+    <summary type="html">&lt;p&gt;Escaped&lt;/p&gt;</summary>
+  </entry>
+  <entry>
+    <id>text</id>
+    <content><![CDATA[<i>Raw</i>]]></content>
+  </entry>
+</feed>`)
+
+	feed, err := Parse(data, "application/atom+xml")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := map[string]string{
+		"xhtml": "<p>Hello <b>world</b></p>",
+		"html":  "<p>Escaped</p>",
+		"text":  "<i>Raw</i>",
+	}
+	for _, item := range feed.Items {
+		if item.Description != want[item.GUID] {
+			t.Errorf("description of %s = %q, want %q", item.GUID, item.Description, want[item.GUID])
+		}
+	}
+}
+
+func TestParseAtomTitle(t *testing.T) {
+	data := []byte(`<?xml version="1.0"?>
+<feed xmlns="http://www.w3.org/2005/Atom">
+  <title type="html">Example &lt;b&gt;feed&lt;/b&gt;</title>
+  <entry>
+    <id>xhtml</id>
+    <title type="xhtml"><div xmlns="http://www.w3.org/1999/xhtml">Hello <b>world</b> &amp; co</div></title>
+  </entry>
+  <entry>
+    <id>html</id>
+    <title type="html">&lt;em&gt;Escaped&lt;/em&gt; &amp;amp; more</title>
+  </entry>
+  <entry>
+    <id>text</id>
+    <title> Plain &lt;text&gt; </title>
+  </entry>
+</feed>`)
+
+	feed, err := Parse(data, "application/atom+xml")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if feed.Title != "Example feed" {
+		t.Errorf("feed title = %q, want %q", feed.Title, "Example feed")
+	}
+
+	want := map[string]string{
+		"xhtml": "Hello world & co",
+		"html":  "Escaped & more",
+		"text":  "Plain <text>",
+	}
+	for _, item := range feed.Items {
+		if item.Title != want[item.GUID] {
+			t.Errorf("title of %s = %q, want %q", item.GUID, item.Title, want[item.GUID])
+		}
+	}
+}
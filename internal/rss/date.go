@@ -0,0 +1,163 @@
+package rss
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// dateLayouts are tried in order, the most common ones first. Layouts use a
+// non-padded day so that both "2 Jan" and "02 Jan" are accepted.
+var dateLayouts = []string{
+	// RFC 1123 and RFC 822 variants used by RSS 2.0
+	"Mon, 2 Jan 2006 15:04:05 -0700",
+	"Mon, 2 Jan 2006 15:04:05 MST",
+	"Mon, 2 Jan 2006 15:04:05 -07:00",
+	"Mon, 2 Jan 2006 15:04 -0700",
+	"Mon, 2 Jan 2006 15:04 MST",
+	"Mon, 2 Jan 06 15:04:05 -0700",
+	"Mon, 2 Jan 06 15:04:05 MST",
+	"Mon, 2 Jan 06 15:04 -0700",
+	"Mon, 2 Jan 06 15:04 MST",
+	"2 Jan 2006 15:04:05 -0700",
+	"2 Jan 2006 15:04:05 MST",
+	"2 Jan 2006 15:04 -0700",
+	"2 Jan 2006 15:04 MST",
+	"2 Jan 06 15:04:05 -0700",
+	"2 Jan 06 15:04:05 MST",
+	"2 Jan 06 15:04 -0700",
+	"2 Jan 06 15:04 MST",
+	"Monday, 2 Jan 2006 15:04:05 MST",
+	time.RFC850,
+	// RFC 3339 and ISO 8601 variants used by Atom, JSON Feed and RDF
+	time.RFC3339,
+	"2006-01-02T15:04Z07:00",
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05 -0700",
+	"2006-01-02 15:04:05 MST",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	// Formats produced by some hand-rolled generators
+	time.UnixDate,
+	time.ANSIC,
+	"January 2, 2006 15:04:05 MST",
+	"January 2, 2006",
+	"Jan 2, 2006",
+}
+
+// zoneOffsets maps the timezone abbreviations found in feeds to their UTC
+// offset in seconds. time.Parse only knows the abbreviations of the local
+// timezone and assumes a zero offset for any other.
+var zoneOffsets = map[string]int{
+	"UT":   0,
+	"UTC":  0,
+	"GMT":  0,
+	"Z":    0,
+	"EST":  -5 * 3600,
+	"EDT":  -4 * 3600,
+	"CST":  -6 * 3600,
+	"CDT":  -5 * 3600,
+	"MST":  -7 * 3600,
+	"MDT":  -6 * 3600,
+	"PST":  -8 * 3600,
+	"PDT":  -7 * 3600,
+	"AKST": -9 * 3600,
+	"AKDT": -8 * 3600,
+	"HST":  -10 * 3600,
+	"WET":  0,
+	"WEST": 1 * 3600,
+	"BST":  1 * 3600,
+	"CET":  1 * 3600,
+	"CEST": 2 * 3600,
+	"EET":  2 * 3600,
+	"EEST": 3 * 3600,
+	"MSK":  3 * 3600,
+	"IST":  5*3600 + 1800,
+	"JST":  9 * 3600,
+	"KST":  9 * 3600,
+	"AEST": 10 * 3600,
+	"AEDT": 11 * 3600,
+	"NZST": 12 * 3600,
+	"NZDT": 13 * 3600,
+}
+
+// ParseDate parses a publication date in any of the formats commonly found
+// in feeds.
+func ParseDate(value string) (time.Time, error) {
+	value = normalizeDate(value)
+	if value == "" {
+		return time.Time{}, fmt.Errorf("empty date")
+	}
+
+	for _, layout := range dateLayouts {
+		t, err := time.Parse(layout, value)
+		if err != nil {
+			continue
+		}
+
+		return withKnownZone(t), nil
+	}
+
+	return time.Time{}, fmt.Errorf("unrecognized date format '%s'", value)
+}
+
+// normalizeDate collapses whitespace, drops a trailing comment such as the
+// "(UTC)" of "+0000 (UTC)" and rewrites timezone spellings that time.Parse
+// does not accept.
+func normalizeDate(value string) string {
+	value = strings.TrimSpace(value)
+	if strings.HasSuffix(value, ")") {
+		if start := strings.LastIndex(value, "("); start > 0 {
+			value = value[:start]
+		}
+	}
+
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return ""
+	}
+
+	last := fields[len(fields)-1]
+	switch last {
+	case "UT", "Z":
+		fields[len(fields)-1] = "UTC"
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// withKnownZone applies the offset of a named timezone that time.Parse could
+// not resolve by itself.
+func withKnownZone(t time.Time) time.Time {
+	name, offset := t.Zone()
+	if offset != 0 {
+		return t
+	}
+
+	knownOffset, ok := zoneOffsets[strings.ToUpper(name)]
+	if !ok || knownOffset == 0 {
+		return t
+	}
+
+	return time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), time.FixedZone(name, knownOffset))
+}
+
+// resolveDates sets the publication time of every item, falling back to the
+// fetch time when the date is missing or cannot be parsed.
+func resolveDates(feed *Feed, fetchedAt time.Time) {
+	for i := range feed.Items {
+		item := &feed.Items[i]
+
+		publishedAt, err := ParseDate(item.PubDate)
+		if err != nil {
+			item.PublishedAt = fetchedAt
+			item.DateEstimated = true
+			continue
+		}
+
+		item.PublishedAt = publishedAt
+	}
+}
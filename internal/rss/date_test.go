@@ -0,0 +1,55 @@
+package rss
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDate(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		want  time.Time
+	}{
+		{"RFC 1123 numeric zone", "Tue, 10 Jun 2003 04:00:00 +0000", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"RFC 1123 offset zone", "Tue, 10 Jun 2003 04:00:00 +0200", time.Date(2003, 6, 10, 2, 0, 0, 0, time.UTC)},
+		{"RFC 1123 GMT", "Tue, 10 Jun 2003 04:00:00 GMT", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"RFC 1123 named zone", "Tue, 10 Jun 2003 04:00:00 EDT", time.Date(2003, 6, 10, 8, 0, 0, 0, time.UTC)},
+		{"RFC 822 UT", "Tue, 10 Jun 2003 04:00:00 UT", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"trailing comment", "Tue, 10 Jun 2003 04:00:00 +0000 (UTC)", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"trailing named comment", "Tue, 10 Jun 2003 00:00:00 -0400 (EDT)", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"non-padded day", "Tue, 3 Jun 2003 04:00:00 +0000", time.Date(2003, 6, 3, 4, 0, 0, 0, time.UTC)},
+		{"padded day", "Tue, 03 Jun 2003 04:00:00 +0000", time.Date(2003, 6, 3, 4, 0, 0, 0, time.UTC)},
+		{"no weekday", "10 Jun 2003 04:00 +0000", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"two-digit year", "Tue, 10 Jun 03 04:00:00 +0000", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"extra whitespace", "  Tue,  10 Jun 2003\t04:00:00 +0000 ", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"RFC 3339", "2003-06-10T04:00:00Z", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"RFC 3339 offset", "2003-06-10T06:00:00+02:00", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"RFC 3339 fraction", "2003-06-10T04:00:00.5Z", time.Date(2003, 6, 10, 4, 0, 0, 500000000, time.UTC)},
+		{"ISO 8601 without zone", "2003-06-10T04:00:00", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"SQL timestamp", "2003-06-10 04:00:00", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"date only", "2003-06-10", time.Date(2003, 6, 10, 0, 0, 0, 0, time.UTC)},
+		{"Unix date", "Tue Jun 10 04:00:00 UTC 2003", time.Date(2003, 6, 10, 4, 0, 0, 0, time.UTC)},
+		{"long month", "June 10, 2003", time.Date(2003, 6, 10, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseDate(tt.value)
+			if err != nil {
+				t.Fatalf("ParseDate(%q) error = %v", tt.value, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("ParseDate(%q) = %v, want %v", tt.value, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDateInvalid(t *testing.T) {
+	for _, value := range []string{"", "   ", "(UTC)", "yesterday", "10/06/2003", "Tue, 32 Jun 2003 04:00:00 +0000"} {
+		if got, err := ParseDate(value); err == nil {
+			t.Errorf("ParseDate(%q) = %v, want an error", value, got)
+		}
+	}
+}
@@ -0,0 +1,275 @@
+package rss
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Format is a feed format that a Feed can be encoded to.
+type Format string
+
+const (
+	RSS  Format = "rss"
+	Atom Format = "atom"
+	JSON Format = "json"
+)
+
+func ParseFormat(s string) (Format, error) {
+	switch format := Format(s); format {
+	case RSS, Atom, JSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown feed format '%s': must be one of rss, atom or json", s)
+	}
+}
+
+// ContentType returns the media type of the format.
+func (f Format) ContentType() string {
+	switch f {
+	case Atom:
+		return "application/atom+xml; charset=utf-8"
+	case JSON:
+		return "application/feed+json; charset=utf-8"
+	default:
+		return "application/rss+xml; charset=utf-8"
+	}
+}
+
+// Encode writes the feed as a document of the given format. Self is the URL
+// the document is published at, which Atom and JSON Feed advertise.
+func Encode(w io.Writer, feed *Feed, format Format, self string) error {
+	switch format {
+	case Atom:
+		return encodeAtom(w, feed, self)
+	case JSON:
+		return encodeJSONFeed(w, feed, self)
+	default:
+		return encodeRSS(w, feed, self)
+	}
+}
+
+// updated returns the publication date of the newest item, or now.
+func updated(feed *Feed) time.Time {
+	latest := time.Time{}
+	for _, item := range feed.Items {
+		if item.PublishedAt.After(latest) {
+			latest = item.PublishedAt
+		}
+	}
+
+	if latest.IsZero() {
+		return time.Now().UTC()
+	}
+
+	return latest
+}
+
+type rssDocument struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Atom    string     `xml:"xmlns:atom,attr"`
+	DC      string     `xml:"xmlns:dc,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string       `xml:"title"`
+	Link          string       `xml:"link"`
+	Description   string       `xml:"description"`
+	Self          *rssAtomLink `xml:"atom:link,omitempty"`
+	LastBuildDate string       `xml:"lastBuildDate"`
+	Items         []rssEntry   `xml:"item"`
+}
+
+type rssAtomLink struct {
+	Href string `xml:"href,attr"`
+	Rel  string `xml:"rel,attr"`
+	Type string `xml:"type,attr"`
+}
+
+type rssGUID struct {
+	Value       string `xml:",chardata"`
+	IsPermaLink bool   `xml:"isPermaLink,attr"`
+}
+
+type rssEntry struct {
+	Title       string  `xml:"title"`
+	Link        string  `xml:"link,omitempty"`
+	Description string  `xml:"description"`
+	GUID        rssGUID `xml:"guid"`
+	PubDate     string  `xml:"pubDate"`
+	Creator     string  `xml:"dc:creator,omitempty"`
+}
+
+func encodeRSS(w io.Writer, feed *Feed, self string) error {
+	doc := rssDocument{
+		Version: "2.0",
+		Atom:    "http://www.w3.org/2005/Atom",
+		DC:      "http://purl.org/dc/elements/1.1/",
+		Channel: rssChannel{
+			Title:         feed.Title,
+			Link:          feed.Link,
+			Description:   feed.Description,
+			LastBuildDate: updated(feed).Format(time.RFC1123Z),
+		},
+	}
+	if self != "" {
+		doc.Channel.Self = &rssAtomLink{Href: self, Rel: "self", Type: "application/rss+xml"}
+	}
+
+	for _, item := range feed.Items {
+		doc.Channel.Items = append(doc.Channel.Items, rssEntry{
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			GUID:        rssGUID{Value: item.GUID, IsPermaLink: false},
+			PubDate:     item.PublishedAt.Format(time.RFC1123Z),
+			Creator:     item.Author,
+		})
+	}
+
+	return encodeXML(w, doc)
+}
+
+type atomDocument struct {
+	XMLName  xml.Name     `xml:"http://www.w3.org/2005/Atom feed"`
+	Title    string       `xml:"title"`
+	Subtitle string       `xml:"subtitle,omitempty"`
+	ID       string       `xml:"id"`
+	Updated  string       `xml:"updated"`
+	Links    []AtomLink   `xml:"link"`
+	Author   atomAuthor   `xml:"author"`
+	Entries  []atomRecord `xml:"entry"`
+}
+
+type atomAuthor struct {
+	Name string `xml:"name"`
+}
+
+type atomText struct {
+	Type  string `xml:"type,attr"`
+	Value string `xml:",chardata"`
+}
+
+type atomRecord struct {
+	ID        string      `xml:"id"`
+	Title     string      `xml:"title"`
+	Links     []AtomLink  `xml:"link"`
+	Updated   string      `xml:"updated"`
+	Published string      `xml:"published"`
+	Summary   atomText    `xml:"summary"`
+	Author    *atomAuthor `xml:"author,omitempty"`
+}
+
+func encodeAtom(w io.Writer, feed *Feed, self string) error {
+	id := self
+	if id == "" {
+		id = feed.Link
+	}
+
+	doc := atomDocument{
+		Title:    feed.Title,
+		Subtitle: feed.Description,
+		ID:       id,
+		Updated:  updated(feed).Format(time.RFC3339),
+		Links:    []AtomLink{{Href: feed.Link, Rel: "alternate"}},
+		// The feed author stands in for the entries without one
+		Author: atomAuthor{Name: feed.Title},
+	}
+	if self != "" {
+		doc.Links = append(doc.Links, AtomLink{Href: self, Rel: "self"})
+	}
+
+	for _, item := range feed.Items {
+		entry := atomRecord{
+			ID:        item.GUID,
+			Title:     item.Title,
+			Updated:   item.PublishedAt.Format(time.RFC3339),
+			Published: item.PublishedAt.Format(time.RFC3339),
+			Summary:   atomText{Type: "html", Value: item.Description},
+		}
+		if item.Link != "" {
+			entry.Links = []AtomLink{{Href: item.Link, Rel: "alternate"}}
+		}
+		if item.Author != "" {
+			entry.Author = &atomAuthor{Name: item.Author}
+		}
+		doc.Entries = append(doc.Entries, entry)
+	}
+
+	return encodeXML(w, doc)
+}
+
+func encodeXML(w io.Writer, doc any) error {
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+
+	encoder := xml.NewEncoder(w)
+	encoder.Indent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode XML: %w", err)
+	}
+
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+type jsonFeedDocument struct {
+	Version     string           `json:"version"`
+	Title       string           `json:"title"`
+	HomePageURL string           `json:"home_page_url,omitempty"`
+	FeedURL     string           `json:"feed_url,omitempty"`
+	Description string           `json:"description,omitempty"`
+	Items       []jsonFeedRecord `json:"items"`
+}
+
+type jsonFeedRecord struct {
+	ID            string           `json:"id"`
+	URL           string           `json:"url,omitempty"`
+	Title         string           `json:"title,omitempty"`
+	ContentHTML   string           `json:"content_html"`
+	DatePublished string           `json:"date_published"`
+	Authors       []jsonFeedAuthor `json:"authors,omitempty"`
+}
+
+type jsonFeedAuthor struct {
+	Name string `json:"name"`
+}
+
+func encodeJSONFeed(w io.Writer, feed *Feed, self string) error {
+	doc := jsonFeedDocument{
+		Version:     "https://jsonfeed.org/version/1.1",
+		Title:       feed.Title,
+		HomePageURL: feed.Link,
+		FeedURL:     self,
+		Description: feed.Description,
+		Items:       []jsonFeedRecord{},
+	}
+
+	for _, item := range feed.Items {
+		record := jsonFeedRecord{
+			ID:            item.GUID,
+			URL:           item.Link,
+			Title:         item.Title,
+			ContentHTML:   item.Description,
+			DatePublished: item.PublishedAt.Format(time.RFC3339),
+		}
+		if item.Author != "" {
+			record.Authors = []jsonFeedAuthor{{Name: item.Author}}
+		}
+		doc.Items = append(doc.Items, record)
+	}
+
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(doc); err != nil {
+		return fmt.Errorf("failed to encode JSON feed: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package rss
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+type JSONFeed struct {
+	Version     string         `json:"version"`
+	Title       string         `json:"title"`
+	HomePageURL string         `json:"home_page_url"`
+	Description string         `json:"description"`
+	Items       []JSONFeedItem `json:"items"`
+}
+
+type JSONFeedItem struct {
+	ID            JSONFeedID       `json:"id"`
+	URL           string           `json:"url"`
+	ExternalURL   string           `json:"external_url"`
+	Title         string           `json:"title"`
+	ContentHTML   string           `json:"content_html"`
+	ContentText   string           `json:"content_text"`
+	Summary       string           `json:"summary"`
+	DatePublished string           `json:"date_published"`
+	DateModified  string           `json:"date_modified"`
+	Authors       []JSONFeedAuthor `json:"authors"`
+	// Author is the JSON Feed 1.0 field, superseded by Authors in 1.1.
+	Author *JSONFeedAuthor `json:"author"`
+}
+
+// JSONFeedID is the id of an item, which readers must accept as a number
+// as well as a string.
+type JSONFeedID string
+
+func (id *JSONFeedID) UnmarshalJSON(data []byte) error {
+	if bytes.Equal(data, []byte("null")) {
+		return nil
+	}
+
+	if bytes.HasPrefix(data, []byte(`"`)) {
+		var value string
+		if err := json.Unmarshal(data, &value); err != nil {
+			return err
+		}
+		*id = JSONFeedID(value)
+		return nil
+	}
+
+	var number json.Number
+	if err := json.Unmarshal(data, &number); err != nil {
+		return fmt.Errorf("invalid item id %s: must be a string or a number", data)
+	}
+	*id = JSONFeedID(number)
+
+	return nil
+}
+
+type JSONFeedAuthor struct {
+	Name string `json:"name"`
+	URL  string `json:"url"`
+}
+
+func parseJSONFeed(data []byte) (*Feed, error) {
+	feed := &Feed{}
+
+	// encoding/json rejects a leading byte order mark
+	data = bytes.TrimPrefix(data, []byte("\ufeff"))
+
+	jsonFeed := &JSONFeed{}
+	if err := json.Unmarshal(data, jsonFeed); err != nil {
+		return feed, fmt.Errorf("failed to unmarshal JSON feed: %w", err)
+	}
+
+	feed.Title = jsonFeed.Title
+	feed.Link = jsonFeed.HomePageURL
+	feed.Description = jsonFeed.Description
+
+	for _, item := range jsonFeed.Items {
+		link := item.URL
+		if link == "" {
+			link = item.ExternalURL
+		}
+
+		description := item.ContentHTML
+		if description == "" {
+			description = item.ContentText
+		}
+		if description == "" {
+			description = item.Summary
+		}
+
+		pubDate := item.DatePublished
+		if pubDate == "" {
+			pubDate = item.DateModified
+		}
+
+		authors := item.Authors
+		if len(authors) == 0 && item.Author != nil {
+			authors = []JSONFeedAuthor{*item.Author}
+		}
+
+		names := []string{}
+		for _, author := range authors {
+			if author.Name != "" {
+				names = append(names, author.Name)
+			}
+		}
+
+		feed.Items = append(feed.Items, Item{
+			GUID:        string(item.ID),
+			Title:       item.Title,
+			Link:        link,
+			Description: description,
+			PubDate:     pubDate,
+			Author:      strings.Join(names, ", "),
+		})
+	}
+
+	return feed, nil
+}
+
+// isJSONFeed reports whether the document is a JSON Feed, either from the
+// response content type or by sniffing the body.
+func isJSONFeed(data []byte, contentType string) bool {
+	mediaType := strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	if mediaType == "application/feed+json" {
+		return true
+	}
+
+	body := strings.TrimLeft(string(data), "\ufeff \t\r\n")
+
+	return strings.HasPrefix(body, "{")
+}
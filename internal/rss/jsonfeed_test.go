@@ -0,0 +1,39 @@
+package rss
+
+import "testing"
+
+func TestParseJSONFeedID(t *testing.T) {
+	data := []byte(`{
+  "version": "https://jsonfeed.org/version/1.1",
+  "title": "Example",
+  "items": [
+    {"id": "https://example.com/1", "url": "https://example.com/1"},
+    {"id": 123, "url": "https://example.com/2"},
+    {"id": 4.5e3, "url": "https://example.com/3"},
+    {"url": "https://example.com/4"}
+  ]
+}`)
+
+	feed, err := Parse(data, "application/feed+json")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	want := []string{"https://example.com/1", "123", "4.5e3", "https://example.com/4"}
+	if len(feed.Items) != len(want) {
+		t.Fatalf("Parse() = %d items, want %d", len(feed.Items), len(want))
+	}
+	for i, item := range feed.Items {
+		if item.GUID != want[i] {
+			t.Errorf("GUID of item %d = %q, want %q", i, item.GUID, want[i])
+		}
+	}
+}
+
+func TestParseJSONFeedInvalidID(t *testing.T) {
+	data := []byte(`{"version": "https://jsonfeed.org/version/1.1", "items": [{"id": {"value": 1}}]}`)
+
+	if _, err := Parse(data, "application/feed+json"); err == nil {
+		t.Error("Parse() with an object id succeeded, want an error")
+	}
+}
@@ -0,0 +1,52 @@
+package rss
+
+import (
+	"encoding/xml"
+	"fmt"
+)
+
+// RDFFeed is an RSS 1.0 document. Unlike RSS 2.0, the items are siblings
+// of the channel rather than children of it.
+type RDFFeed struct {
+	Channel struct {
+		Title       string `xml:"title"`
+		Link        string `xml:"link"`
+		Description string `xml:"description"`
+	} `xml:"channel"`
+	Item []RDFItem `xml:"item"`
+}
+
+type RDFItem struct {
+	About       string `xml:"http://www.w3.org/1999/02/22-rdf-syntax-ns# about,attr"`
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	Description string `xml:"description"`
+	Date        string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	Creator     string `xml:"http://purl.org/dc/elements/1.1/ creator"`
+}
+
+func parseRDF(data []byte) (*Feed, error) {
+	feed := &Feed{}
+
+	rdfFeed := &RDFFeed{}
+	if err := xml.Unmarshal(data, rdfFeed); err != nil {
+		return feed, fmt.Errorf("failed to unmarshal RDF XML: %w", err)
+	}
+
+	feed.Title = rdfFeed.Channel.Title
+	feed.Link = rdfFeed.Channel.Link
+	feed.Description = rdfFeed.Channel.Description
+
+	for _, item := range rdfFeed.Item {
+		feed.Items = append(feed.Items, Item{
+			GUID:        item.About,
+			Title:       item.Title,
+			Link:        item.Link,
+			Description: item.Description,
+			PubDate:     item.Date,
+			Author:      item.Creator,
+		})
+	}
+
+	return feed, nil
+}
@@ -1,6 +1,7 @@
 package rss
 
 import (
+	"bytes"
 	"context"
 	"encoding/xml"
 	"fmt"
@@ -8,32 +9,107 @@ import (
 	"io"
 	"net/http"
 	"net/url"
+	"strconv"
+	"strings"
+	"time"
 )
 
+// Feed is the format-agnostic representation of a fetched feed. Every
+// supported format is normalized into it so that consumers never have to
+// care about the shape of the original document.
+type Feed struct {
+	Title       string
+	Link        string
+	Description string
+	Items       []Item
+	// TTL, SkipHours and SkipDays are the RSS 2.0 scheduling hints. Hours
+	// are in GMT.
+	TTL       time.Duration
+	SkipHours []int
+	SkipDays  []time.Weekday
+	// ETag and LastModified are the cache validators sent by the server, to
+	// be replayed on the next fetch.
+	ETag         string
+	LastModified string
+	// NotModified is set when the server answered 304 Not Modified, in which
+	// case the feed has no content.
+	NotModified bool
+}
+
+// Item is a single entry of a Feed.
+type Item struct {
+	// GUID identifies the item within its feed. It falls back to Link when
+	// the feed does not provide one.
+	GUID        string
+	Title       string
+	Link        string
+	Description string
+	PubDate     string
+	Author      string
+	// PublishedAt is PubDate parsed, or the fetch time when DateEstimated
+	// is set because PubDate is missing or malformed.
+	PublishedAt   time.Time
+	DateEstimated bool
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
 type RSSFeed struct {
 	Channel struct {
 		Title       string    `xml:"title"`
-		Link        string    `xml:"link"`
+		Links       []RSSLink `xml:"link"`
 		Description string    `xml:"description"`
+		TTL         string    `xml:"ttl"`
+		SkipHours   []string  `xml:"skipHours>hour"`
+		SkipDays    []string  `xml:"skipDays>day"`
 		Item        []RSSItem `xml:"item"`
 	} `xml:"channel"`
 }
 
+// RSSLink is a link element, which may be an empty atom:link as well since
+// both share the same local name.
+type RSSLink struct {
+	XMLName xml.Name
+	Href    string `xml:",chardata"`
+}
+
 type RSSItem struct {
-	Title       string `xml:"title"`
-	Link        string `xml:"link"`
-	Description string `xml:"description"`
-	PubDate     string `xml:"pubDate"`
+	GUID        string    `xml:"guid"`
+	Title       string    `xml:"title"`
+	Links       []RSSLink `xml:"link"`
+	Description string    `xml:"description"`
+	PubDate     string    `xml:"pubDate"`
+	Author      string    `xml:"author"`
+	// Dublin Core elements are common in RSS 2.0 feeds as well
+	DCDate    string `xml:"http://purl.org/dc/elements/1.1/ date"`
+	DCCreator string `xml:"http://purl.org/dc/elements/1.1/ creator"`
 }
 
-func FetchFeed(ctx context.Context, u string) (*RSSFeed, error) {
-	feed := &RSSFeed{}
+// FetchFeed downloads and parses the feed at the given URL. When etag or
+// lastModified are set, the request is conditional and a 304 Not Modified
+// response yields an empty Feed with NotModified set.
+func FetchFeed(ctx context.Context, u string, etag string, lastModified string) (*Feed, error) {
+	feed := &Feed{}
 
 	request, err := http.NewRequestWithContext(ctx, "GET", u, nil)
 	if err != nil {
 		return feed, fmt.Errorf("failed to create request: %w", err)
 	}
 	request.Header.Set("User-Agent", "gator")
+	if etag != "" {
+		request.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		request.Header.Set("If-Modified-Since", lastModified)
+	}
 
 	client := &http.Client{}
 	response, err := client.Do(request)
@@ -42,26 +118,171 @@ func FetchFeed(ctx context.Context, u string) (*RSSFeed, error) {
 	}
 	defer response.Body.Close()
 
+	if response.StatusCode == http.StatusNotModified {
+		feed.NotModified = true
+		feed.ETag = etag
+		feed.LastModified = lastModified
+		if value := response.Header.Get("ETag"); value != "" {
+			feed.ETag = value
+		}
+		if value := response.Header.Get("Last-Modified"); value != "" {
+			feed.LastModified = value
+		}
+		return feed, nil
+	}
+
 	if response.StatusCode != http.StatusOK {
 		return feed, fmt.Errorf("request resulted in status code %d %s\v", response.StatusCode, response.Status)
 	}
 
-	bytes, err := io.ReadAll(response.Body)
+	data, err := io.ReadAll(response.Body)
 	if err != nil {
 		return feed, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if err := xml.Unmarshal(bytes, feed); err != nil {
-		return feed, fmt.Errorf("failed to unmarshal XML: %w", err)
+	feed, err = Parse(data, response.Header.Get("Content-Type"))
+	if err != nil {
+		return feed, err
+	}
+
+	feed.ETag = response.Header.Get("ETag")
+	feed.LastModified = response.Header.Get("Last-Modified")
+
+	return feed, nil
+}
+
+// Parse detects the format of the given document, using the content type
+// when it is known, and normalizes it into a Feed.
+func Parse(data []byte, contentType string) (*Feed, error) {
+	feed, err := parse(data, contentType)
+	if err != nil {
+		return feed, err
 	}
 
-	feed.Channel.Title = html.UnescapeString(feed.Channel.Title)
-	feed.Channel.Description = html.UnescapeString(feed.Channel.Description)
-	link, err := url.Parse(feed.Channel.Link)
+	feed.Title = html.UnescapeString(feed.Title)
+	feed.Description = html.UnescapeString(feed.Description)
+	link, err := url.Parse(feed.Link)
 	if err != nil {
 		return feed, fmt.Errorf("failed to parse channel link: %w", err)
 	}
-	feed.Channel.Link = link.String()
+	feed.Link = link.String()
+
+	for i := range feed.Items {
+		item := &feed.Items[i]
+		item.GUID = strings.TrimSpace(item.GUID)
+		if item.GUID == "" {
+			item.GUID = item.Link
+		}
+	}
+
+	resolveDates(feed, time.Now().UTC())
 
 	return feed, nil
 }
+
+func parse(data []byte, contentType string) (*Feed, error) {
+	if isJSONFeed(data, contentType) {
+		return parseJSONFeed(data)
+	}
+
+	root, err := rootElement(data)
+	if err != nil {
+		return &Feed{}, fmt.Errorf("failed to detect feed format: %w", err)
+	}
+
+	switch root {
+	case "rss":
+		return parseRSS(data)
+	case "feed":
+		return parseAtom(data)
+	case "RDF":
+		return parseRDF(data)
+	default:
+		return &Feed{}, fmt.Errorf("unsupported feed format with root element <%s>", root)
+	}
+}
+
+func parseRSS(data []byte) (*Feed, error) {
+	feed := &Feed{}
+
+	rssFeed := &RSSFeed{}
+	if err := xml.Unmarshal(data, rssFeed); err != nil {
+		return feed, fmt.Errorf("failed to unmarshal XML: %w", err)
+	}
+
+	feed.Title = rssFeed.Channel.Title
+	feed.Link = rssLink(rssFeed.Channel.Links)
+	feed.Description = rssFeed.Channel.Description
+
+	if ttl, err := strconv.Atoi(strings.TrimSpace(rssFeed.Channel.TTL)); err == nil && ttl > 0 {
+		feed.TTL = time.Duration(ttl) * time.Minute
+	}
+
+	for _, hourStr := range rssFeed.Channel.SkipHours {
+		hour, err := strconv.Atoi(strings.TrimSpace(hourStr))
+		if err != nil || hour < 0 || hour > 24 {
+			continue
+		}
+		// Some feeds number the hours from 1 to 24
+		feed.SkipHours = append(feed.SkipHours, hour%24)
+	}
+
+	for _, dayStr := range rssFeed.Channel.SkipDays {
+		if day, ok := weekdays[strings.ToLower(strings.TrimSpace(dayStr))]; ok {
+			feed.SkipDays = append(feed.SkipDays, day)
+		}
+	}
+
+	for _, item := range rssFeed.Channel.Item {
+		pubDate := item.PubDate
+		if pubDate == "" {
+			pubDate = item.DCDate
+		}
+
+		author := item.DCCreator
+		if author == "" {
+			author = item.Author
+		}
+
+		feed.Items = append(feed.Items, Item{
+			GUID:        item.GUID,
+			Title:       item.Title,
+			Link:        rssLink(item.Links),
+			Description: item.Description,
+			PubDate:     pubDate,
+			Author:      author,
+		})
+	}
+
+	return feed, nil
+}
+
+// rssLink returns the URL of the RSS link element, ignoring the atom:link
+// ones.
+func rssLink(links []RSSLink) string {
+	for _, link := range links {
+		if link.XMLName.Space == "" {
+			return link.Href
+		}
+	}
+
+	return ""
+}
+
+// rootElement returns the local name of the first element of an XML
+// document.
+func rootElement(data []byte) (string, error) {
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.Strict = false
+
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return "", fmt.Errorf("failed to read XML token: %w", err)
+		}
+
+		if start, ok := token.(xml.StartElement); ok {
+			return start.Name.Local, nil
+		}
+	}
+}
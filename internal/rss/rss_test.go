@@ -0,0 +1,33 @@
+package rss
+
+import "testing"
+
+func TestParseRSSLink(t *testing.T) {
+	data := []byte(`<?xml version="1.0"?>
+<rss version="2.0" xmlns:atom="http://www.w3.org/2005/Atom">
+  <channel>
+    <title>Example</title>
+    <link>https://example.com/</link>
+    <atom:link href="https://example.com/feed.xml" rel="self" type="application/rss+xml"/>
+    <description>An example feed</description>
+    <item>
+      <title>Post</title>
+      <atom:link href="https://example.com/comments" rel="replies"/>
+      <link>https://example.com/post</link>
+      <atom:link href="https://example.com/post.xml" rel="self"/>
+    </item>
+  </channel>
+</rss>`)
+
+	feed, err := Parse(data, "application/rss+xml")
+	if err != nil {
+		t.Fatalf("Parse() error = %v", err)
+	}
+
+	if feed.Link != "https://example.com/" {
+		t.Errorf("feed link = %q, want %q", feed.Link, "https://example.com/")
+	}
+	if len(feed.Items) != 1 || feed.Items[0].Link != "https://example.com/post" {
+		t.Errorf("items = %+v, want one item linking to https://example.com/post", feed.Items)
+	}
+}
@@ -0,0 +1,109 @@
+package scraper
+
+import (
+	"gator/internal/database"
+	"gator/internal/rss"
+	"slices"
+	"time"
+)
+
+const (
+	minFetchInterval     = 15 * time.Minute
+	maxFetchInterval     = 24 * time.Hour
+	defaultFetchInterval = time.Hour
+	maxFailureBackoff    = 24 * time.Hour
+	// postingHistorySize is the number of most recent posts used to estimate
+	// how often a feed publishes.
+	postingHistorySize = 10
+)
+
+// fetchInterval estimates how long to wait before fetching a feed again from
+// the average gap between its most recent posts, bounded by
+// minFetchInterval and maxFetchInterval. The feed TTL, when set, is a lower
+// bound.
+func fetchInterval(feed *rss.Feed) time.Duration {
+	dates := []time.Time{}
+	for _, item := range feed.Items {
+		if !item.DateEstimated {
+			dates = append(dates, item.PublishedAt)
+		}
+	}
+
+	slices.SortFunc(dates, func(a, b time.Time) int {
+		return b.Compare(a)
+	})
+	if len(dates) > postingHistorySize {
+		dates = dates[:postingHistorySize]
+	}
+
+	interval := defaultFetchInterval
+	if len(dates) > 1 {
+		interval = dates[0].Sub(dates[len(dates)-1]) / time.Duration(len(dates)-1)
+	}
+
+	interval = min(max(interval, minFetchInterval), maxFetchInterval)
+
+	if feed.TTL > interval {
+		interval = feed.TTL
+	}
+
+	return interval
+}
+
+// nextFetchDelay returns how long to wait from now before the next fetch,
+// pushing it past the hours and days the feed asks to be skipped.
+func nextFetchDelay(feed *rss.Feed, interval time.Duration, now time.Time) time.Duration {
+	next := now.Add(interval).UTC()
+
+	// Bounded by a week of hours in case every hour is skipped
+	for range 24 * 7 {
+		if !slices.Contains(feed.SkipHours, next.Hour()) && !slices.Contains(feed.SkipDays, next.Weekday()) {
+			break
+		}
+		next = next.Truncate(time.Hour).Add(time.Hour)
+	}
+
+	return next.Sub(now)
+}
+
+// storedSkipHints returns the skip hints of a feed as they are stored in its
+// row.
+func storedSkipHints(feed *rss.Feed) ([]int32, []int32) {
+	hours := []int32{}
+	for _, hour := range feed.SkipHours {
+		hours = append(hours, int32(hour))
+	}
+
+	days := []int32{}
+	for _, day := range feed.SkipDays {
+		days = append(days, int32(day))
+	}
+
+	return hours, days
+}
+
+// loadSkipHints sets the skip hints of a feed from the ones stored in its
+// row, for responses that have none.
+func loadSkipHints(feed *rss.Feed, row database.Feed) {
+	feed.SkipHours = nil
+	for _, hour := range row.SkipHours {
+		feed.SkipHours = append(feed.SkipHours, int(hour))
+	}
+
+	feed.SkipDays = nil
+	for _, day := range row.SkipDays {
+		feed.SkipDays = append(feed.SkipDays, time.Weekday(day))
+	}
+}
+
+// failureBackoff returns how long to wait before retrying a feed that failed
+// failures times in a row, doubling from minFetchInterval up to
+// maxFailureBackoff.
+func failureBackoff(failures int32) time.Duration {
+	backoff := minFetchInterval
+	for i := int32(1); i < failures && backoff < maxFailureBackoff; i++ {
+		backoff *= 2
+	}
+
+	return min(backoff, maxFailureBackoff)
+}
@@ -2,30 +2,161 @@ package scraper
 
 import (
 	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"gator/internal/database"
 	"gator/internal/rss"
 	"gator/internal/state"
-	"strings"
+	"sync"
 	"time"
+
+	"github.com/google/uuid"
 )
 
-func ScrapeFeeds(s *state.State) error {
-	feed, err := s.Queries.GetNextFeedToFetch(context.Background())
+// Stats counts what happened during one or more scrapes.
+type Stats struct {
+	Feeds     int
+	Failures  int
+	New       int
+	Updated   int
+	Unchanged int
+}
+
+// Add accumulates the counts of other into st.
+func (st *Stats) Add(other Stats) {
+	st.Feeds += other.Feeds
+	st.Failures += other.Failures
+	st.New += other.New
+	st.Updated += other.Updated
+	st.Unchanged += other.Unchanged
+}
+
+// ScrapeFeeds claims up to concurrency due feeds and scrapes them in parallel
+// with as many workers. Claimed feeds are locked with SKIP LOCKED so that
+// several aggregators can share the same database.
+func ScrapeFeeds(ctx context.Context, s *state.State, concurrency int) (Stats, error) {
+	stats := Stats{}
+
+	feeds, err := s.Queries.GetNextFeedToFetch(ctx, int32(concurrency))
 	if err != nil {
-		return fmt.Errorf("error getting next feed to fetch: %w", err)
+		return stats, fmt.Errorf("error getting next feeds to fetch: %w", err)
+	}
+
+	jobs := make(chan database.Feed)
+	// Each feed can report a scrape error and a failure to record it
+	errs := make(chan error, 2*len(feeds))
+
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for feed := range jobs {
+				feedStats, err := scrapeFeed(ctx, s, feed)
+				if err != nil {
+					feedStats.Failures++
+				} else {
+					fmt.Printf("feed '%s': %d new, %d updated, %d unchanged posts\n", feed.Name, feedStats.New, feedStats.Updated, feedStats.Unchanged)
+				}
+
+				mu.Lock()
+				stats.Add(feedStats)
+				mu.Unlock()
+
+				if err == nil {
+					continue
+				}
+
+				errs <- fmt.Errorf("feed '%s': %w", feed.Name, err)
+
+				// An interrupted scrape is not the feed's fault
+				if ctx.Err() != nil {
+					continue
+				}
+
+				if err := markFeedAsFailed(ctx, s, feed, err); err != nil {
+					errs <- fmt.Errorf("feed '%s': %w", feed.Name, err)
+				}
+			}
+		}()
+	}
+
+	for _, feed := range feeds {
+		jobs <- feed
+	}
+	close(jobs)
+
+	wg.Wait()
+	close(errs)
+
+	scrapeErrs := []error{}
+	for err := range errs {
+		scrapeErrs = append(scrapeErrs, err)
+	}
+
+	return stats, errors.Join(scrapeErrs...)
+}
+
+// markFeedAsFailed records the scrape error and delays the next fetch with
+// an exponential backoff.
+func markFeedAsFailed(ctx context.Context, s *state.State, feed database.Feed, scrapeErr error) error {
+	delay := failureBackoff(feed.ConsecutiveFailures + 1)
+
+	if err := s.Queries.MarkFeedAsFailed(ctx, database.MarkFeedAsFailedParams{
+		ID:                    feed.ID,
+		LastError:             scrapeErr.Error(),
+		NextFetchDelaySeconds: int32(delay.Seconds()),
+	}); err != nil {
+		return fmt.Errorf("failed to mark feed as failed: %w", err)
 	}
 
-	rssFeed, err := rss.FetchFeed(context.Background(), feed.Url)
+	return nil
+}
+
+// scrapeFeed fetches a feed and upserts its posts by GUID, counting new,
+// updated and unchanged posts.
+func scrapeFeed(ctx context.Context, s *state.State, feed database.Feed) (Stats, error) {
+	stats := Stats{Feeds: 1}
+
+	fetchedFeed, err := rss.FetchFeed(ctx, feed.Url, feed.Etag, feed.LastModified)
 	if err != nil {
-		return fmt.Errorf("failed to fetch feed: %w", err)
+		return stats, fmt.Errorf("failed to fetch feed: %w", err)
 	}
 
-	if err := s.Queries.MarkFeedAsFetched(context.Background(), feed.ID); err != nil {
-		return fmt.Errorf("failed to mark feed as fetched: %w", err)
+	// A feed that was not modified has no posts to learn from nor hints, so
+	// its previous interval and skip hints are kept
+	interval := time.Duration(feed.FetchIntervalSeconds) * time.Second
+	if fetchedFeed.NotModified {
+		loadSkipHints(fetchedFeed, feed)
+	} else {
+		interval = fetchInterval(fetchedFeed)
 	}
+	delay := nextFetchDelay(fetchedFeed, interval, time.Now())
+	skipHours, skipDays := storedSkipHints(fetchedFeed)
 
-	for _, item := range rssFeed.Channel.Item {
+	if err := s.Queries.MarkFeedAsFetched(ctx, database.MarkFeedAsFetchedParams{
+		ID:                    feed.ID,
+		Etag:                  fetchedFeed.ETag,
+		LastModified:          fetchedFeed.LastModified,
+		NextFetchDelaySeconds: int32(delay.Seconds()),
+		FetchIntervalSeconds:  int32(interval.Seconds()),
+		SkipHours:             skipHours,
+		SkipDays:              skipDays,
+		Link:                  fetchedFeed.Link,
+	}); err != nil {
+		return stats, fmt.Errorf("failed to mark feed as fetched: %w", err)
+	}
+
+	if fetchedFeed.NotModified {
+		fmt.Printf("feed '%s' was not modified since last fetch\n", feed.Name)
+		return stats, nil
+	}
+
+	for _, item := range fetchedFeed.Items {
 		if item.Link == "" {
 			fmt.Printf("skipping post with title '%s': no link provided\n", item.Title)
 			continue
@@ -36,25 +167,68 @@ func ScrapeFeeds(s *state.State) error {
 			continue
 		}
 
-		pubDate, err := time.Parse(time.RFC1123Z, item.PubDate)
-		if err != nil {
-			return fmt.Errorf("failed to parse publication date '%s': %w", item.PubDate, err)
+		if item.DateEstimated {
+			fmt.Printf("post with title '%s': invalid publication date '%s', using fetch time instead\n", item.Title, item.PubDate)
 		}
 
-		if _, err := s.Queries.CreatePost(context.Background(), database.CreatePostParams{
-			Title:       item.Title,
-			Description: item.Description,
-			Url:         item.Link,
-			PublishedAt: pubDate,
-			FeedID:      feed.ID,
-		}); err != nil {
-			if strings.Contains(err.Error(), "duplicate key") {
-				fmt.Printf("skipping post with title '%s': url already exists\n", item.Title)
-				continue
+		// Posts stored before GUIDs were captured have their URL as GUID, and
+		// take the GUID of their item rather than being inserted again
+		if item.GUID != item.Link {
+			if err := s.Queries.AdoptLegacyPost(ctx, database.AdoptLegacyPostParams{
+				Guid:   item.GUID,
+				FeedID: uuid.NullUUID{UUID: feed.ID, Valid: true},
+				Url:    item.Link,
+			}); err != nil {
+				return stats, fmt.Errorf("failed to adopt legacy post: %w", err)
 			}
-			return fmt.Errorf("failed to create post: %w", err)
+		}
+
+		row, err := s.Queries.UpsertPost(ctx, database.UpsertPostParams{
+			Title:                item.Title,
+			Description:          item.Description,
+			Url:                  item.Link,
+			PublishedAt:          item.PublishedAt.UTC(),
+			PublishedAtEstimated: item.DateEstimated,
+			FeedID:               uuid.NullUUID{UUID: feed.ID, Valid: true},
+			Guid:                 item.GUID,
+			ContentHash:          contentHash(item),
+			Author:               item.Author,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			// The post exists and its content hash did not change
+			stats.Unchanged++
+			continue
+		}
+		if err != nil {
+			return stats, fmt.Errorf("failed to upsert post: %w", err)
+		}
+
+		if row.Inserted {
+			stats.New++
+		} else {
+			stats.Updated++
 		}
 	}
 
-	return nil
+	return stats, nil
+}
+
+// contentHash fingerprints the parts of an item that are stored, so that an
+// edited item updates its post. Estimated dates change on every fetch and
+// are left out.
+func contentHash(item rss.Item) string {
+	hash := sha256.New()
+	hash.Write([]byte(item.Title))
+	hash.Write([]byte{0})
+	hash.Write([]byte(item.Link))
+	hash.Write([]byte{0})
+	hash.Write([]byte(item.Description))
+	hash.Write([]byte{0})
+	hash.Write([]byte(item.Author))
+	hash.Write([]byte{0})
+	if !item.DateEstimated {
+		hash.Write([]byte(item.PublishedAt.UTC().Format(time.RFC3339)))
+	}
+
+	return hex.EncodeToString(hash.Sum(nil))
 }
@@ -0,0 +1,342 @@
+// Package web serves a server-rendered reader UI for the current user.
+package web
+
+import (
+	"bytes"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"gator/internal/api"
+	"gator/internal/cursor"
+	"gator/internal/database"
+	"gator/internal/feeds"
+	"html"
+	"html/template"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+//go:embed templates static
+var assets embed.FS
+
+const (
+	postsPerPage  = 20
+	summaryLength = 300
+)
+
+var tags = regexp.MustCompile(`<[^>]*>`)
+
+var funcs = template.FuncMap{
+	"feedName": feedName,
+	"summary":  summary,
+}
+
+// feedName returns the name of the followed feed of a post.
+func feedName(follows []database.GetFeedFollowsByUserRow, feedID uuid.NullUUID) string {
+	for _, follow := range follows {
+		if feedID.Valid && follow.Feed.ID == feedID.UUID {
+			return follow.Feed.Name
+		}
+	}
+	return "Unknown feed"
+}
+
+// summary turns the HTML description of a post into a short plain text, so
+// that the markup of the feeds is never rendered.
+func summary(description string) string {
+	text := html.UnescapeString(tags.ReplaceAllString(description, " "))
+	text = strings.Join(strings.Fields(text), " ")
+
+	if runes := []rune(text); len(runes) > summaryLength {
+		text = string(runes[:summaryLength]) + "…"
+	}
+
+	return text
+}
+
+// Server serves the reader of a single user, the one logged in with
+// 'gator login'.
+type Server struct {
+	store    api.Store
+	username string
+	index    *template.Template
+	mux      *http.ServeMux
+}
+
+func NewServer(store api.Store, username string) (*Server, error) {
+	index, err := template.New("index.html").Funcs(funcs).ParseFS(assets, "templates/index.html")
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse templates: %w", err)
+	}
+
+	static, err := fs.Sub(assets, "static")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load static assets: %w", err)
+	}
+
+	srv := &Server{store: store, username: username, index: index, mux: http.NewServeMux()}
+
+	srv.mux.Handle("GET /static/", http.StripPrefix("/static/", http.FileServerFS(static)))
+	srv.mux.HandleFunc("GET /{$}", srv.river)
+	srv.mux.HandleFunc("POST /feeds", srv.authed(srv.addFeed))
+	srv.mux.HandleFunc("POST /follow", srv.authed(srv.follow))
+	srv.mux.HandleFunc("POST /unfollow", srv.authed(srv.unfollow))
+
+	return srv, nil
+}
+
+func (srv *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Forms can only be posted from the reader itself
+	if r.Method == http.MethodPost {
+		if origin, err := url.Parse(r.Header.Get("Origin")); err == nil && origin.Host != "" && origin.Host != r.Host {
+			http.Error(w, "cross-origin request refused", http.StatusForbidden)
+			return
+		}
+	}
+
+	srv.mux.ServeHTTP(w, r)
+}
+
+type page struct {
+	User       string
+	Follows    []database.GetFeedFollowsByUserRow
+	Unfollowed []database.Feed
+	Feed       *database.Feed
+	Posts      []cursor.PostRow
+	All        bool
+	NextPage   string
+	Error      string
+	values     url.Values
+}
+
+// Query returns the link to the current page with key set to value, or
+// removed when value is empty. Changing a filter starts over from the first
+// page.
+func (p page) Query(key string, value string) template.URL {
+	values := url.Values{}
+	for k, v := range p.values {
+		values[k] = v
+	}
+
+	if key != "page" {
+		values.Del("page")
+	}
+	if value == "" {
+		values.Del(key)
+	} else {
+		values.Set(key, value)
+	}
+
+	return template.URL("?" + values.Encode())
+}
+
+// river renders the followed feeds and the posts of the current user,
+// optionally filtered by the feed query parameter.
+func (srv *Server) river(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	user, err := srv.store.GetUser(r.Context(), srv.username)
+	if err != nil {
+		srv.fail(w, r, fmt.Errorf("failed to get user '%s': %w", srv.username, err))
+		return
+	}
+
+	data := page{
+		User:   user.Name,
+		All:    query.Get("all") == "true",
+		Error:  query.Get("error"),
+		values: query,
+	}
+	data.values.Del("error")
+
+	data.Follows, err = srv.store.GetFeedFollowsByUser(r.Context(), user.ID)
+	if err != nil {
+		srv.fail(w, r, fmt.Errorf("failed to get feeds for user '%s': %w", user.Name, err))
+		return
+	}
+
+	feeds, err := srv.store.GetAllFeedsWithUsers(r.Context())
+	if err != nil {
+		srv.fail(w, r, fmt.Errorf("failed to get feeds: %w", err))
+		return
+	}
+
+	followed := map[uuid.UUID]bool{}
+	for _, follow := range data.Follows {
+		followed[follow.Feed.ID] = true
+	}
+	for _, row := range feeds {
+		if !followed[row.Feed.ID] {
+			data.Unfollowed = append(data.Unfollowed, row.Feed)
+		}
+	}
+
+	params := cursor.PostsParams{
+		ID:          user.ID,
+		IncludeRead: data.All,
+		Limit:       postsPerPage,
+	}
+
+	if value := query.Get("feed"); value != "" {
+		for _, follow := range data.Follows {
+			if follow.Feed.ID.String() == value {
+				data.Feed = &follow.Feed
+			}
+		}
+		if data.Feed == nil {
+			srv.redirect(w, r, "/", "you are not following this feed")
+			return
+		}
+		params.FeedID = uuid.NullUUID{UUID: data.Feed.ID, Valid: true}
+	}
+
+	if value := query.Get("page"); value != "" {
+		publishedAt, id, err := cursor.Decode(value)
+		if err != nil {
+			srv.redirect(w, r, "/", err.Error())
+			return
+		}
+		params.CursorPublishedAt = sql.NullTime{Time: publishedAt, Valid: true}
+		params.CursorID = uuid.NullUUID{UUID: id, Valid: true}
+	}
+
+	data.Posts, err = cursor.Posts(r.Context(), srv.store, params)
+	if err != nil {
+		srv.fail(w, r, fmt.Errorf("failed to get posts for user '%s': %w", user.Name, err))
+		return
+	}
+
+	if len(data.Posts) == int(params.Limit) {
+		last := data.Posts[len(data.Posts)-1].Post
+		data.NextPage = cursor.Encode(last.PublishedAt, last.ID)
+	}
+
+	// Render to a buffer so that a template error does not send half a page
+	buf := &bytes.Buffer{}
+	if err := srv.index.Execute(buf, data); err != nil {
+		srv.fail(w, r, fmt.Errorf("failed to render page: %w", err))
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	buf.WriteTo(w)
+}
+
+// authed requires the credentials of the user of the reader, the ones of the
+// API, for the forms that change data.
+func (srv *Server) authed(handler func(w http.ResponseWriter, r *http.Request, user database.User)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		user, err := api.Authenticate(r.Context(), srv.store, r)
+		if err == nil && user.Name != srv.username {
+			err = &api.Error{Status: http.StatusForbidden, Message: fmt.Sprintf("the reader is the one of user '%s'", srv.username)}
+		}
+
+		apiErr := &api.Error{}
+		if errors.As(err, &apiErr) {
+			if apiErr.Status == http.StatusUnauthorized {
+				w.Header().Set("WWW-Authenticate", `Basic realm="gator"`)
+			}
+			http.Error(w, apiErr.Message, apiErr.Status)
+			return
+		}
+		if err != nil {
+			srv.fail(w, r, err)
+			return
+		}
+
+		handler(w, r, user)
+	}
+}
+
+// addFeed creates a feed and follows it, like 'gator addfeed'.
+func (srv *Server) addFeed(w http.ResponseWriter, r *http.Request, user database.User) {
+	name := strings.TrimSpace(r.FormValue("name"))
+	if name == "" {
+		srv.redirect(w, r, "/", "a name and an http or https URL are required to add a feed")
+		return
+	}
+
+	var feed database.Feed
+	err := srv.store.InTx(r.Context(), func(q database.Querier) error {
+		var err error
+		feed, err = feeds.Add(r.Context(), q, user, name, r.FormValue("url"))
+		return err
+	})
+	if err != nil {
+		srv.redirect(w, r, "/", err.Error())
+		return
+	}
+
+	srv.redirect(w, r, "/?feed="+feed.ID.String(), "")
+}
+
+func (srv *Server) follow(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedURL := r.FormValue("url")
+
+	feed, err := srv.store.GetFeedByUrl(r.Context(), feedURL)
+	if err != nil {
+		srv.redirect(w, r, "/", fmt.Sprintf("failed to get feed by URL '%s': %v", feedURL, err))
+		return
+	}
+
+	_, err = srv.store.CreateFeedFollow(r.Context(), database.CreateFeedFollowParams{
+		FeedID: feed.ID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		srv.redirect(w, r, "/", fmt.Sprintf("failed to follow feed '%s': %v", feed.Name, err))
+		return
+	}
+
+	srv.redirect(w, r, "/?feed="+feed.ID.String(), "")
+}
+
+func (srv *Server) unfollow(w http.ResponseWriter, r *http.Request, user database.User) {
+	feedID, err := uuid.Parse(r.FormValue("feed"))
+	if err != nil {
+		srv.redirect(w, r, "/", "invalid feed")
+		return
+	}
+
+	err = srv.store.DeleteFeedFollow(r.Context(), database.DeleteFeedFollowParams{
+		FeedID: feedID,
+		UserID: user.ID,
+	})
+	if err != nil {
+		srv.redirect(w, r, "/", fmt.Sprintf("failed to unfollow feed: %v", err))
+		return
+	}
+
+	srv.redirect(w, r, "/", "")
+}
+
+// redirect sends the browser back to a page after a form, with the error
+// shown there when message is set.
+func (srv *Server) redirect(w http.ResponseWriter, r *http.Request, target string, message string) {
+	if message != "" {
+		separator := "?"
+		if strings.Contains(target, "?") {
+			separator = "&"
+		}
+		target += separator + "error=" + url.QueryEscape(message)
+	}
+
+	http.Redirect(w, r, target, http.StatusSeeOther)
+}
+
+func (srv *Server) fail(w http.ResponseWriter, r *http.Request, err error) {
+	status := http.StatusInternalServerError
+	if errors.Is(err, sql.ErrNoRows) {
+		status = http.StatusNotFound
+	}
+
+	log.Printf("error handling %s %s: %v", r.Method, r.URL.Path, err)
+	http.Error(w, err.Error(), status)
+}
@@ -5,7 +5,6 @@ import (
 	"gator/internal/config"
 	"gator/internal/database"
 	"gator/internal/handlers"
-	"gator/internal/middlewares"
 	"gator/internal/state"
 	"log"
 	"os"
@@ -30,25 +29,15 @@ func main() {
 	s.Queries = database.New(db)
 
 	cmds := handlers.Commands{
-		Cmds: make(map[string]func(*state.State, handlers.Command) error),
+		Cmds:        make(map[string]*handlers.Spec),
+		CheckSchema: handlers.CheckSchema,
 	}
-
-	cmds.Register("login", handlers.Login)
-	cmds.Register("register", handlers.Register)
-	cmds.Register("reset", handlers.Reset)
-	cmds.Register("users", handlers.Users)
-	cmds.Register("agg", handlers.Agg)
-	cmds.Register("addfeed", middlewares.LoggedIn(handlers.AddFeed))
-	cmds.Register("feeds", handlers.Feeds)
-	cmds.Register("follow", handlers.Follow)
-	cmds.Register("following", handlers.Following)
-	cmds.Register("unfollow", middlewares.LoggedIn(handlers.Unfollow))
-	cmds.Register("browse", middlewares.LoggedIn(handlers.Browse))
+	registerCommands(&cmds)
 
 	args := os.Args
 
 	if len(args) < 2 {
-		log.Fatalf("Usage: gator <command name> [args]")
+		log.Fatalf("Usage: gator <command name> [args], see 'gator help'")
 	}
 
 	cmd := handlers.Command{Name: args[1], Args: args[2:]}
@@ -0,0 +1,17 @@
+-- name: GetApiCredential :one
+SELECT
+    *
+FROM
+    api_credentials
+WHERE
+    user_id = $1;
+
+-- name: SetApiPassword :exec
+INSERT INTO
+    api_credentials (user_id, password_hash)
+VALUES
+    ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET
+    password_hash = EXCLUDED.password_hash,
+    updated_at = NOW();
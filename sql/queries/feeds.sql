@@ -6,32 +6,91 @@ VALUES
 RETURNING
     *;
 
--- name: GetFeedByUrl :one
+-- name: GetFeedByID :one
 SELECT
     feeds.*
 FROM
     feeds
 WHERE
-    url = $1;
+    id = $1;
 
--- name: GetNextFeedToFetch :one
+-- name: GetFeedByUrl :one
 SELECT
-    *
+    feeds.*
 FROM
     feeds
-ORDER BY
-    last_fetched_at ASC NULLS FIRST
-LIMIT
-    1;
+WHERE
+    url = $1;
+
+-- name: GetNextFeedToFetch :many
+UPDATE
+    feeds
+SET
+    last_fetched_at = NOW(),
+    next_fetch_at = NOW() + make_interval(secs => fetch_interval_seconds),
+    updated_at = NOW()
+WHERE
+    id IN (
+        SELECT
+            id
+        FROM
+            feeds
+        WHERE
+            next_fetch_at <= NOW()
+        ORDER BY
+            next_fetch_at ASC
+        LIMIT
+            $1
+        FOR UPDATE
+            SKIP LOCKED
+    )
+RETURNING
+    *;
 
 -- name: MarkFeedAsFetched :exec
 UPDATE
     feeds
 SET
     last_fetched_at = NOW(),
+    etag = sqlc.arg(etag),
+    last_modified = sqlc.arg(last_modified),
+    next_fetch_at = NOW() + sqlc.arg(next_fetch_delay_seconds)::INTEGER * INTERVAL '1 second',
+    fetch_interval_seconds = sqlc.arg(fetch_interval_seconds),
+    skip_hours = sqlc.arg(skip_hours),
+    skip_days = sqlc.arg(skip_days),
+    link = CASE
+        WHEN sqlc.arg(link)::TEXT = '' THEN link
+        ELSE sqlc.arg(link)
+    END,
+    last_error = '',
+    consecutive_failures = 0,
     updated_at = NOW()
 WHERE
-    id = $1;
+    id = sqlc.arg(id);
+
+-- name: MarkFeedAsFailed :exec
+UPDATE
+    feeds
+SET
+    last_fetched_at = NOW(),
+    last_error = sqlc.arg(last_error),
+    last_error_at = NOW(),
+    consecutive_failures = consecutive_failures + 1,
+    next_fetch_at = NOW() + sqlc.arg(next_fetch_delay_seconds)::INTEGER * INTERVAL '1 second',
+    updated_at = NOW()
+WHERE
+    id = sqlc.arg(id);
+
+-- name: GetFailingFeeds :many
+SELECT
+    *
+FROM
+    feeds
+WHERE
+    consecutive_failures > 0
+ORDER BY
+    consecutive_failures DESC,
+    last_error_at DESC;
 
 -- name: GetAllFeedsWithUsers :many
 SELECT
@@ -44,9 +103,9 @@ FROM
 -- name: CreateFeedFollow :one
 WITH inserted_feed_follow AS (
     INSERT INTO
-        feed_follows (feed_id, user_id)
+        feed_follows (feed_id, user_id, category)
     VALUES
-        ($1, $2)
+        ($1, $2, $3)
     RETURNING
         *
 )
@@ -62,7 +121,8 @@ FROM
 -- name: GetFeedFollowsByUser :many
 SELECT
     sqlc.embed(feeds),
-    sqlc.embed(users)
+    sqlc.embed(users),
+    feed_follows.category
 FROM
     feed_follows
     INNER JOIN feeds ON feed_follows.feed_id = feeds.id
@@ -76,3 +136,9 @@ DELETE FROM
 WHERE
     feed_id = $1
     AND user_id = $2;
+
+-- name: DeleteFeed :execrows
+DELETE FROM
+    feeds
+WHERE
+    id = $1;
@@ -0,0 +1,18 @@
+-- name: GetUserByFeverApiKey :one
+SELECT
+    users.*
+FROM
+    users
+    INNER JOIN fever_credentials ON users.id = fever_credentials.user_id
+WHERE
+    fever_credentials.api_key = $1;
+
+-- name: SetFeverApiKey :exec
+INSERT INTO
+    fever_credentials (user_id, api_key)
+VALUES
+    ($1, $2)
+ON CONFLICT (user_id) DO UPDATE
+SET
+    api_key = EXCLUDED.api_key,
+    updated_at = NOW();
@@ -0,0 +1,147 @@
+-- name: SetPostRead :exec
+INSERT INTO
+    post_states (user_id, post_id, read, read_at)
+VALUES
+    (
+        sqlc.arg(user_id),
+        sqlc.arg(post_id),
+        sqlc.arg(read),
+        CASE
+            WHEN sqlc.arg(read)::BOOLEAN THEN NOW()
+        END
+    )
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET
+    read = EXCLUDED.read,
+    read_at = EXCLUDED.read_at,
+    updated_at = NOW();
+
+-- name: MarkAllPostsRead :execrows
+INSERT INTO
+    post_states (user_id, post_id, read, read_at)
+SELECT
+    feed_follows.user_id,
+    posts.id,
+    TRUE,
+    NOW()
+FROM
+    posts
+    INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE
+    feed_follows.user_id = sqlc.arg(user_id)
+    AND (
+        sqlc.narg(feed_id)::UUID IS NULL
+        OR posts.feed_id = sqlc.narg(feed_id)
+    )
+    AND (
+        sqlc.narg(until)::TIMESTAMP IS NULL
+        OR posts.published_at < sqlc.narg(until)
+    )
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET
+    read = TRUE,
+    read_at = NOW(),
+    updated_at = NOW()
+WHERE
+    NOT post_states.read;
+
+-- name: SetPostStarred :exec
+INSERT INTO
+    post_states (user_id, post_id, starred, starred_at)
+VALUES
+    (
+        sqlc.arg(user_id),
+        sqlc.arg(post_id),
+        sqlc.arg(starred),
+        CASE
+            WHEN sqlc.arg(starred)::BOOLEAN THEN NOW()
+        END
+    )
+ON CONFLICT (user_id, post_id) DO UPDATE
+SET
+    starred = EXCLUDED.starred,
+    starred_at = EXCLUDED.starred_at,
+    updated_at = NOW();
+
+-- name: GetStarredPostsForUser :many
+SELECT
+    sqlc.embed(posts),
+    post_states.starred_at
+FROM
+    post_states
+    INNER JOIN posts ON post_states.post_id = posts.id
+WHERE
+    post_states.user_id = $1
+    AND post_states.starred
+ORDER BY
+    post_states.starred_at DESC;
+
+-- name: GetNewestStarredPostsForUser :many
+SELECT
+    sqlc.embed(posts),
+    sqlc.embed(users),
+    post_states.read,
+    post_states.starred
+FROM
+    post_states
+    INNER JOIN posts ON post_states.post_id = posts.id
+    INNER JOIN users ON post_states.user_id = users.id
+WHERE
+    users.id = sqlc.arg(id)
+    AND post_states.starred
+    AND (
+        sqlc.arg(include_read)::BOOLEAN
+        OR NOT post_states.read
+    )
+    AND (
+        sqlc.narg(since)::TIMESTAMP IS NULL
+        OR posts.published_at >= sqlc.narg(since)
+    )
+    AND (
+        sqlc.narg(until)::TIMESTAMP IS NULL
+        OR posts.published_at < sqlc.narg(until)
+    )
+    AND (posts.published_at, posts.id) < (
+        sqlc.arg(cursor_published_at)::TIMESTAMP,
+        sqlc.arg(cursor_id)::UUID
+    )
+ORDER BY
+    posts.published_at DESC,
+    posts.id DESC
+LIMIT
+    sqlc.arg('limit');
+
+-- name: GetOldestStarredPostsForUser :many
+SELECT
+    sqlc.embed(posts),
+    sqlc.embed(users),
+    post_states.read,
+    post_states.starred
+FROM
+    post_states
+    INNER JOIN posts ON post_states.post_id = posts.id
+    INNER JOIN users ON post_states.user_id = users.id
+WHERE
+    users.id = sqlc.arg(id)
+    AND post_states.starred
+    AND (
+        sqlc.arg(include_read)::BOOLEAN
+        OR NOT post_states.read
+    )
+    AND (
+        sqlc.narg(since)::TIMESTAMP IS NULL
+        OR posts.published_at >= sqlc.narg(since)
+    )
+    AND (
+        sqlc.narg(until)::TIMESTAMP IS NULL
+        OR posts.published_at < sqlc.narg(until)
+    )
+    AND (posts.published_at, posts.id) > (
+        sqlc.arg(cursor_published_at)::TIMESTAMP,
+        sqlc.arg(cursor_id)::UUID
+    )
+ORDER BY
+    posts.published_at ASC,
+    posts.id ASC
+LIMIT
+    sqlc.arg('limit');
@@ -1,31 +1,300 @@
--- name: CreatePost :one
+-- name: SearchPostsForUser :many
+SELECT
+    sqlc.embed(posts),
+    feeds.name AS feed_name,
+    ts_rank(
+        setweight(to_tsvector('english', posts.title), 'A') || setweight(to_tsvector('english', posts.description), 'B'),
+        search_query
+    )::REAL AS rank,
+    ts_headline(
+        'english',
+        posts.title || ' ' || posts.description,
+        search_query,
+        'StartSel=<mark>, StopSel=</mark>, MaxFragments=2, MaxWords=20, MinWords=10'
+    )::TEXT AS snippet
+FROM
+    posts
+    INNER JOIN feeds ON posts.feed_id = feeds.id
+    INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id,
+    websearch_to_tsquery('english', sqlc.arg(query)) AS search_query
+WHERE
+    feed_follows.user_id = sqlc.arg(user_id)
+    AND (
+        setweight(to_tsvector('english', posts.title), 'A') || setweight(to_tsvector('english', posts.description), 'B')
+    ) @@ search_query
+    AND (
+        sqlc.narg(feed_id)::UUID IS NULL
+        OR posts.feed_id = sqlc.narg(feed_id)
+    )
+    AND (
+        sqlc.narg(since)::TIMESTAMP IS NULL
+        OR posts.published_at >= sqlc.narg(since)
+    )
+ORDER BY
+    rank DESC,
+    posts.published_at DESC
+LIMIT
+    sqlc.arg('limit');
+
+-- name: UpsertPost :one
 INSERT INTO
-    posts (title, url, description, published_at, feed_id)
+    posts (
+        title,
+        url,
+        description,
+        published_at,
+        published_at_estimated,
+        feed_id,
+        guid,
+        content_hash,
+        author
+    )
 VALUES
-    ($1, $2, $3, $4, $5)
+    ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+ON CONFLICT (feed_id, guid) DO UPDATE
+SET
+    title = EXCLUDED.title,
+    url = EXCLUDED.url,
+    description = EXCLUDED.description,
+    published_at = CASE
+        WHEN EXCLUDED.published_at_estimated THEN posts.published_at
+        ELSE EXCLUDED.published_at
+    END,
+    published_at_estimated = posts.published_at_estimated
+    AND EXCLUDED.published_at_estimated,
+    content_hash = EXCLUDED.content_hash,
+    author = EXCLUDED.author,
+    updated_at = NOW()
+WHERE
+    posts.content_hash <> EXCLUDED.content_hash
 RETURNING
-    *;
+    *,
+    (xmax = 0)::BOOLEAN AS inserted;
+
+-- name: AdoptLegacyPost :exec
+UPDATE
+    posts
+SET
+    guid = sqlc.arg(guid)
+WHERE
+    feed_id = sqlc.arg(feed_id)
+    AND guid = sqlc.arg(url)
+    AND NOT EXISTS (
+        SELECT
+            1
+        FROM
+            posts AS existing
+        WHERE
+            existing.feed_id = sqlc.arg(feed_id)
+            AND existing.guid = sqlc.arg(guid)
+    );
 
 -- name: GetPostByUrl :one
+SELECT
+    posts.*
+FROM
+    posts
+    INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE
+    feed_follows.user_id = sqlc.arg(user_id)
+    AND posts.url = sqlc.arg(url)
+ORDER BY
+    posts.published_at DESC
+LIMIT
+    1;
+
+-- name: GetPostByID :one
 SELECT
     *
 FROM
     posts
 WHERE
-    url = $1;
+    id = $1;
 
--- name: GetPostsForUser :many
+-- name: GetNewestPostsForUser :many
 SELECT
     sqlc.embed(posts),
-    sqlc.embed(users)
+    sqlc.embed(users),
+    COALESCE(post_states.read, FALSE)::BOOLEAN AS read,
+    COALESCE(post_states.starred, FALSE)::BOOLEAN AS starred
 FROM
     posts
     INNER JOIN feeds ON posts.feed_id = feeds.id
     INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id
     INNER JOIN users ON feed_follows.user_id = users.id
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND users.id = post_states.user_id
 WHERE
-    users.id = $1
+    users.id = sqlc.arg(id)
+    AND (
+        sqlc.arg(include_read)::BOOLEAN
+        OR NOT COALESCE(post_states.read, FALSE)
+    )
+    AND (
+        sqlc.narg(feed_id)::UUID IS NULL
+        OR posts.feed_id = sqlc.narg(feed_id)
+    )
+    AND (
+        sqlc.narg(category)::TEXT IS NULL
+        OR feed_follows.category = sqlc.narg(category)
+    )
+    AND (
+        sqlc.narg(since)::TIMESTAMP IS NULL
+        OR posts.published_at >= sqlc.narg(since)
+    )
+    AND (
+        sqlc.narg(until)::TIMESTAMP IS NULL
+        OR posts.published_at < sqlc.narg(until)
+    )
+    AND (posts.published_at, posts.id) < (
+        sqlc.arg(cursor_published_at)::TIMESTAMP,
+        sqlc.arg(cursor_id)::UUID
+    )
 ORDER BY
-    posts.published_at DESC
+    posts.published_at DESC,
+    posts.id DESC
 LIMIT
-    $2;
+    sqlc.arg('limit')
+OFFSET
+    sqlc.arg('offset');
+
+-- name: GetOldestPostsForUser :many
+SELECT
+    sqlc.embed(posts),
+    sqlc.embed(users),
+    COALESCE(post_states.read, FALSE)::BOOLEAN AS read,
+    COALESCE(post_states.starred, FALSE)::BOOLEAN AS starred
+FROM
+    posts
+    INNER JOIN feeds ON posts.feed_id = feeds.id
+    INNER JOIN feed_follows ON feeds.id = feed_follows.feed_id
+    INNER JOIN users ON feed_follows.user_id = users.id
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND users.id = post_states.user_id
+WHERE
+    users.id = sqlc.arg(id)
+    AND (
+        sqlc.arg(include_read)::BOOLEAN
+        OR NOT COALESCE(post_states.read, FALSE)
+    )
+    AND (
+        sqlc.narg(feed_id)::UUID IS NULL
+        OR posts.feed_id = sqlc.narg(feed_id)
+    )
+    AND (
+        sqlc.narg(category)::TEXT IS NULL
+        OR feed_follows.category = sqlc.narg(category)
+    )
+    AND (
+        sqlc.narg(since)::TIMESTAMP IS NULL
+        OR posts.published_at >= sqlc.narg(since)
+    )
+    AND (
+        sqlc.narg(until)::TIMESTAMP IS NULL
+        OR posts.published_at < sqlc.narg(until)
+    )
+    AND (posts.published_at, posts.id) > (
+        sqlc.arg(cursor_published_at)::TIMESTAMP,
+        sqlc.arg(cursor_id)::UUID
+    )
+ORDER BY
+    posts.published_at ASC,
+    posts.id ASC
+LIMIT
+    sqlc.arg('limit')
+OFFSET
+    sqlc.arg('offset');
+
+-- name: GetPostsForUserByApiIDs :many
+SELECT
+    sqlc.embed(posts),
+    COALESCE(post_states.read, FALSE)::BOOLEAN AS read,
+    COALESCE(post_states.starred, FALSE)::BOOLEAN AS starred
+FROM
+    posts
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND post_states.user_id = sqlc.arg(user_id)
+WHERE
+    posts.api_id = ANY(sqlc.arg(api_ids)::BIGINT[])
+    AND (
+        post_states.starred
+        OR EXISTS (
+            SELECT
+                1
+            FROM
+                feed_follows
+            WHERE
+                feed_follows.feed_id = posts.feed_id
+                AND feed_follows.user_id = sqlc.arg(user_id)
+        )
+    )
+ORDER BY
+    posts.published_at DESC,
+    posts.id DESC;
+
+-- name: GetUnreadCountsForUser :many
+SELECT
+    posts.feed_id,
+    COUNT(*) AS count,
+    MAX(posts.published_at)::TIMESTAMP AS newest_published_at
+FROM
+    posts
+    INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND feed_follows.user_id = post_states.user_id
+WHERE
+    feed_follows.user_id = $1
+    AND NOT COALESCE(post_states.read, FALSE)
+GROUP BY
+    posts.feed_id;
+
+-- name: GetPostsForUserByApiIDRange :many
+SELECT
+    sqlc.embed(posts),
+    COALESCE(post_states.read, FALSE)::BOOLEAN AS read,
+    COALESCE(post_states.starred, FALSE)::BOOLEAN AS starred
+FROM
+    posts
+    INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND feed_follows.user_id = post_states.user_id
+WHERE
+    feed_follows.user_id = sqlc.arg(user_id)
+    AND (
+        sqlc.narg(since_id)::BIGINT IS NULL
+        OR posts.api_id > sqlc.narg(since_id)
+    )
+    AND (
+        sqlc.narg(max_id)::BIGINT IS NULL
+        OR posts.api_id < sqlc.narg(max_id)
+    )
+ORDER BY
+    CASE
+        WHEN sqlc.narg(max_id)::BIGINT IS NULL THEN posts.api_id
+    END ASC,
+    posts.api_id DESC
+LIMIT
+    sqlc.arg('limit');
+
+-- name: CountPostsForUser :one
+SELECT
+    COUNT(*)
+FROM
+    posts
+    INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+WHERE
+    feed_follows.user_id = $1;
+
+-- name: GetUnreadPostApiIDsForUser :many
+SELECT
+    posts.api_id
+FROM
+    posts
+    INNER JOIN feed_follows ON posts.feed_id = feed_follows.feed_id
+    LEFT JOIN post_states ON posts.id = post_states.post_id
+    AND feed_follows.user_id = post_states.user_id
+WHERE
+    feed_follows.user_id = $1
+    AND NOT COALESCE(post_states.read, FALSE)
+ORDER BY
+    posts.api_id;
@@ -0,0 +1,9 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN etag TEXT NOT NULL DEFAULT '',
+ADD COLUMN last_modified TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN etag,
+DROP COLUMN last_modified;
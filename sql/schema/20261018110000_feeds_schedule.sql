@@ -0,0 +1,17 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN next_fetch_at TIMESTAMP NOT NULL DEFAULT NOW(),
+ADD COLUMN fetch_interval_seconds INTEGER NOT NULL DEFAULT 3600,
+ADD COLUMN skip_hours INTEGER[] NOT NULL DEFAULT '{}',
+ADD COLUMN skip_days INTEGER[] NOT NULL DEFAULT '{}';
+
+CREATE INDEX feeds_next_fetch_at_idx ON feeds (next_fetch_at);
+
+-- +goose Down
+DROP INDEX feeds_next_fetch_at_idx;
+
+ALTER TABLE feeds
+DROP COLUMN next_fetch_at,
+DROP COLUMN fetch_interval_seconds,
+DROP COLUMN skip_hours,
+DROP COLUMN skip_days;
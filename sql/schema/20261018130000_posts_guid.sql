@@ -0,0 +1,26 @@
+-- +goose Up
+ALTER TABLE posts
+ADD COLUMN guid TEXT NOT NULL DEFAULT '',
+ADD COLUMN content_hash TEXT NOT NULL DEFAULT '';
+
+UPDATE
+    posts
+SET
+    guid = url;
+
+ALTER TABLE posts
+DROP CONSTRAINT posts_url_key,
+ADD CONSTRAINT unique_feed_guid UNIQUE (feed_id, guid);
+
+CREATE INDEX posts_feed_id_url_idx ON posts (feed_id, url);
+
+-- +goose Down
+DROP INDEX posts_feed_id_url_idx;
+
+ALTER TABLE posts
+DROP CONSTRAINT unique_feed_guid,
+ADD CONSTRAINT posts_url_key UNIQUE (url);
+
+ALTER TABLE posts
+DROP COLUMN guid,
+DROP COLUMN content_hash;
@@ -0,0 +1,53 @@
+-- +goose Up
+ALTER TABLE post_states
+ADD COLUMN starred BOOLEAN NOT NULL DEFAULT FALSE,
+ADD COLUMN starred_at TIMESTAMP;
+
+-- Starred posts outlive their feed: the posts of a deleted feed are removed
+-- unless a user starred them, in which case they are detached from it
+ALTER TABLE posts
+ALTER COLUMN feed_id DROP NOT NULL,
+DROP CONSTRAINT posts_feed_id_fkey,
+ADD CONSTRAINT posts_feed_id_fkey FOREIGN KEY (feed_id) REFERENCES feeds(id) ON UPDATE CASCADE ON DELETE SET NULL;
+
+-- +goose StatementBegin
+CREATE FUNCTION delete_unstarred_feed_posts() RETURNS TRIGGER AS $$
+BEGIN
+    DELETE FROM
+        posts
+    WHERE
+        feed_id = OLD.id
+        AND NOT EXISTS (
+            SELECT
+                1
+            FROM
+                post_states
+            WHERE
+                post_states.post_id = posts.id
+                AND post_states.starred
+        );
+    RETURN OLD;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER delete_unstarred_feed_posts BEFORE DELETE ON feeds FOR EACH ROW EXECUTE FUNCTION delete_unstarred_feed_posts();
+
+-- +goose Down
+DROP TRIGGER delete_unstarred_feed_posts ON feeds;
+
+DROP FUNCTION delete_unstarred_feed_posts;
+
+DELETE FROM
+    posts
+WHERE
+    feed_id IS NULL;
+
+ALTER TABLE posts
+ALTER COLUMN feed_id SET NOT NULL,
+DROP CONSTRAINT posts_feed_id_fkey,
+ADD CONSTRAINT posts_feed_id_fkey FOREIGN KEY (feed_id) REFERENCES feeds(id) ON UPDATE CASCADE ON DELETE CASCADE;
+
+ALTER TABLE post_states
+DROP COLUMN starred,
+DROP COLUMN starred_at;
@@ -0,0 +1,10 @@
+-- +goose Up
+-- The search query must repeat this exact expression to use the index
+CREATE INDEX posts_search_idx ON posts USING GIN (
+    (
+        setweight(to_tsvector('english', title), 'A') || setweight(to_tsvector('english', description), 'B')
+    )
+);
+
+-- +goose Down
+DROP INDEX posts_search_idx;
@@ -0,0 +1,7 @@
+-- +goose Up
+ALTER TABLE feeds
+ADD COLUMN link TEXT NOT NULL DEFAULT '';
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN link;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- Sync clients identify the posts by integers rather than UUIDs
+ALTER TABLE posts
+ADD COLUMN api_id BIGINT GENERATED BY DEFAULT AS IDENTITY UNIQUE;
+
+-- +goose Down
+ALTER TABLE posts
+DROP COLUMN api_id;
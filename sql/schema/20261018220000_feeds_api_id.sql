@@ -0,0 +1,8 @@
+-- +goose Up
+-- Fever clients identify the feeds by integers rather than UUIDs
+ALTER TABLE feeds
+ADD COLUMN api_id BIGINT GENERATED BY DEFAULT AS IDENTITY UNIQUE;
+
+-- +goose Down
+ALTER TABLE feeds
+DROP COLUMN api_id;
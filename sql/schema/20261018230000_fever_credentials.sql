@@ -0,0 +1,12 @@
+-- +goose Up
+-- Fever clients authenticate with the unsalted MD5 of 'username:password',
+-- so the Fever password is a generated one, distinct from the API password
+CREATE TABLE fever_credentials (
+    user_id UUID PRIMARY KEY REFERENCES users(id) ON UPDATE CASCADE ON DELETE CASCADE,
+    api_key TEXT NOT NULL UNIQUE,
+    created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+    updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- +goose Down
+DROP TABLE fever_credentials;